@@ -1,6 +1,30 @@
 package client
 
-import "contabo.com/openapi"
+import (
+	"net/http"
+
+	"contabo.com/openapi"
+)
+
+// Options tunes the http client talking to the API.
+type Options struct {
+	// MaxConcurrentMutations caps the in-flight mutating requests, 0 means unlimited.
+	MaxConcurrentMutations int
+	// TLSCACert is a PEM encoded CA bundle or the path to it, trusted in addition to the system roots.
+	TLSCACert string
+	// TLSInsecure disables the verification of the server certificates.
+	TLSInsecure bool
+	// ProxyURL routes all requests through the proxy instead of the one from the environment.
+	ProxyURL string
+	// ReadRetries is the retry policy of reading requests.
+	ReadRetries RetryPolicy
+	// MutationRetries is the retry policy of mutating requests.
+	MutationRetries RetryPolicy
+	// RequestLogPath is the file every API call is appended to as a JSON line, empty disables the log.
+	RequestLogPath string
+	// Noop answers mutating requests with a synthesized success instead of sending them.
+	Noop bool
+}
 
 func NewClient(
 	apiUrl string,
@@ -9,11 +33,18 @@ func NewClient(
 	clientSecret *string,
 	username string,
 	password *string,
+	options Options,
 ) (*openapi.APIClient, error) {
 	configuration := openapi.NewConfiguration()
 	configuration.AddDefaultHeader("x-trace-id", "contabo_terraform_provider")
 
+	transport, err := NewTransport(options)
+	if err != nil {
+		return nil, err
+	}
+
 	httpClient, err := BearerHttpClient(
+		&http.Client{Transport: transport},
 		authUrl,
 		clientId,
 		*clientSecret,
@@ -25,6 +56,19 @@ func NewClient(
 		return nil, err
 	}
 
+	loggedTransport, err := NewRequestLogTransport(NewNoopTransport(httpClient.Transport, options.Noop), options.RequestLogPath)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient.Transport = NewRateLimitTransport(
+		NewRetryTransport(
+			NewLimitedTransport(loggedTransport, options.MaxConcurrentMutations),
+			options.ReadRetries,
+			options.MutationRetries,
+		),
+		rateLimitWarningThreshold,
+	)
 	configuration.HTTPClient = httpClient
 
 	var server openapi.ServerConfiguration
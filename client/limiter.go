@@ -0,0 +1,51 @@
+package client
+
+import "net/http"
+
+// limitedTransport caps the number of concurrently running mutating requests,
+// e.g. add-on upgrades and assignments, of all resources sharing the client.
+// Reading requests are not limited.
+type limitedTransport struct {
+	base      http.RoundTripper
+	semaphore chan struct{}
+}
+
+// NewLimitedTransport wraps the base transport so that at most maxConcurrent
+// mutating requests are in flight. A non positive maxConcurrent disables the
+// limit.
+func NewLimitedTransport(base http.RoundTripper, maxConcurrent int) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if maxConcurrent <= 0 {
+		return base
+	}
+
+	return &limitedTransport{
+		base:      base,
+		semaphore: make(chan struct{}, maxConcurrent),
+	}
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isMutatingRequest(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	select {
+	case t.semaphore <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	defer func() { <-t.semaphore }()
+
+	return t.base.RoundTrip(req)
+}
+
+func isMutatingRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimitedTransportCapsConcurrentMutations(t *testing.T) {
+	var mutex sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mutex.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mutex.Lock()
+		inFlight--
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: NewLimitedTransport(http.DefaultTransport, 2)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := httpClient.Post(server.URL, "application/json", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight != 2 {
+		t.Fatalf("expected at most 2 concurrent mutating requests, got %d", maxInFlight)
+	}
+}
+
+func TestLimitedTransportDoesNotLimitReads(t *testing.T) {
+	transport := NewLimitedTransport(http.DefaultTransport, 1).(*limitedTransport)
+	// occupy the only slot, reading requests must pass nonetheless
+	transport.semaphore <- struct{}{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	resp, err := (&http.Client{Transport: transport, Timeout: time.Second}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the read not to be limited, got %v", err)
+	}
+	resp.Body.Close()
+}
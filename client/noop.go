@@ -0,0 +1,95 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// NoopEnvVar is the environment variable enabling the no-op mode, in which
+// mutating requests are not sent to the API.
+const NoopEnvVar = "TF_CONTABO_NOOP"
+
+// noopTransport answers mutating requests itself instead of sending them to
+// the API, so that an apply can be rehearsed against a real account. Reading
+// requests are sent as usual.
+type noopTransport struct {
+	base http.RoundTripper
+	warn func(ctx context.Context, msg string)
+}
+
+// NewNoopTransport wraps the base transport so that mutating requests are
+// logged and answered with a synthesized success if noop is set. The response
+// of a create or update holds the request payload as its only data object, a
+// delete is answered with 204 No Content. Nothing is created, so reading a
+// created resource afterwards fails.
+func NewNoopTransport(base http.RoundTripper, noop bool) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if !noop {
+		return base
+	}
+
+	return &noopTransport{
+		base: base,
+		warn: func(ctx context.Context, msg string) { tflog.Warn(ctx, msg) },
+	}
+}
+
+func (t *noopTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isMutatingRequest(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	t.warn(req.Context(), fmt.Sprintf("%s is set, not sending %s %s", NoopEnvVar, req.Method, req.URL.Path))
+
+	resp := &http.Response{
+		Status:     http.StatusText(http.StatusNoContent),
+		StatusCode: http.StatusNoContent,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		Request:    req,
+	}
+	if req.Method == http.MethodDelete {
+		return resp, nil
+	}
+
+	payload := json.RawMessage("{}")
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if json.Valid(body) && bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+			payload = body
+		}
+	}
+
+	responseBody, err := json.Marshal(map[string]interface{}{
+		"data":   []json.RawMessage{payload},
+		"_links": map[string]string{"self": req.URL.Path},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp.StatusCode = http.StatusOK
+	if req.Method == http.MethodPost {
+		resp.StatusCode = http.StatusCreated
+	}
+	resp.Status = http.StatusText(resp.StatusCode)
+	resp.Header.Set("Content-Type", "application/json")
+	resp.ContentLength = int64(len(responseBody))
+	resp.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+	return resp, nil
+}
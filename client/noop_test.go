@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNoopTransportSendsNoMutatingRequests(t *testing.T) {
+	sent := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.Method)
+	}))
+	defer server.Close()
+
+	transport := NewNoopTransport(http.DefaultTransport, true).(*noopTransport)
+	warnings := []string{}
+	transport.warn = func(ctx context.Context, msg string) { warnings = append(warnings, msg) }
+	httpClient := &http.Client{Transport: transport}
+
+	for _, c := range []struct {
+		method     string
+		body       string
+		statusCode int
+		response   string
+	}{
+		{http.MethodPost, `{"name":"rehearsal"}`, http.StatusCreated, `{"_links":{"self":"/v1/private-networks"},"data":[{"name":"rehearsal"}]}`},
+		{http.MethodPatch, `{"description":"changed"}`, http.StatusOK, `{"_links":{"self":"/v1/private-networks"},"data":[{"description":"changed"}]}`},
+		{http.MethodDelete, ``, http.StatusNoContent, ``},
+		{http.MethodGet, ``, http.StatusOK, ``},
+	} {
+		req, _ := http.NewRequest(c.method, server.URL+"/v1/private-networks", strings.NewReader(c.body))
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != c.statusCode || string(body) != c.response {
+			t.Fatalf("expected %s to be answered with %d %s, got %d %s", c.method, c.statusCode, c.response, resp.StatusCode, body)
+		}
+	}
+
+	if len(sent) != 1 || sent[0] != http.MethodGet {
+		t.Fatalf("expected only the reading request to be sent, got %v", sent)
+	}
+	if len(warnings) != 3 || !strings.Contains(warnings[0], "not sending POST /v1/private-networks") {
+		t.Fatalf("expected a warning per mutating request, got %v", warnings)
+	}
+}
+
+func TestNoopTransportDisabled(t *testing.T) {
+	if NewNoopTransport(http.DefaultTransport, false) != http.DefaultTransport {
+		t.Fatal("expected the base transport if the no-op mode is disabled")
+	}
+}
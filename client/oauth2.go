@@ -2,7 +2,9 @@ package client
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -17,13 +19,15 @@ import (
 )
 
 func BearerHttpClient(
+	baseHttpClient *http.Client,
 	authUrl string,
 	clientId string,
 	clientSecret string,
 	username string,
 	password string,
 ) (*http.Client, error) {
-	ctx := context.Background()
+	// the token requests and the authorized requests use the base client
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, baseHttpClient)
 	configuration := &oauth2.Config{
 		ClientID: clientId,
 		ClientSecret: clientSecret,
@@ -32,8 +36,14 @@ func BearerHttpClient(
 		},
 	}
 
+	// reuse the token of an earlier configuration of this account
+	key := tokenCacheKey{clientId: clientId, username: username, tokenUrl: authUrl}
+	if token := cachedToken(key); token != nil {
+		return cachingClient(ctx, configuration, key, token), nil
+	}
+
 	// check if token has been cached
-	token, err := RestoreTokenFromCache()
+	token, err := RestoreTokenFromCache(key)
 
 	if err != nil {
 		return nil, err
@@ -46,22 +56,29 @@ func BearerHttpClient(
 		}
 	}
 
-	err = cacheToken(token)
+	err = cacheToken(key, token)
 
 	if err != nil {
 		return nil, err
 	}
+	storeCachedToken(key, token)
+
+	return cachingClient(ctx, configuration, key, token), nil
+}
 
-	return configuration.Client(ctx, token), nil
+// cachingClient returns a client authorized by the token, which keeps the
+// tokens it refreshes in the token cache.
+func cachingClient(ctx context.Context, configuration *oauth2.Config, key tokenCacheKey, token *oauth2.Token) *http.Client {
+	return oauth2.NewClient(ctx, &cachingTokenSource{key: key, source: configuration.TokenSource(ctx, token)})
 }
 
-func cacheToken(token *oauth2.Token) error {
+func cacheToken(key tokenCacheKey, token *oauth2.Token) error {
 	serializedToken, err := hprose.Serialize(token, true)
 	if err != nil {
 		return fmt.Errorf("could not serialize token due to erros %v", err)
 	}
 
-	tokenCacheFileName, err := getCacheFile()
+	tokenCacheFileName, err := getCacheFile(key)
 	if err != nil {
 		return err
 	}
@@ -80,8 +97,8 @@ func cacheToken(token *oauth2.Token) error {
 	return nil
 }
 
-func RestoreTokenFromCache() (*oauth2.Token, error) {
-	tokenCacheFileName, err := getCacheFile()
+func RestoreTokenFromCache(key tokenCacheKey) (*oauth2.Token, error) {
+	tokenCacheFileName, err := getCacheFile(key)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +131,8 @@ func RestoreTokenFromCache() (*oauth2.Token, error) {
 	return &token, nil
 }
 
-func getCacheFile() (*string, error) {
+// getCacheFile returns the path of the file caching the token of the account.
+func getCacheFile(key tokenCacheKey) (*string, error) {
 	home, err := homedir.Dir()
 	if err != nil {
 		return nil, fmt.Errorf("could not determine home dir: %v", err)
@@ -124,6 +142,7 @@ func getCacheFile() (*string, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not ensure cache folder: %v", err)
 	}
-	tokenCacheFileName := home + "/.cache/contabo/terraform/token"
+	hash := sha256.Sum256([]byte(key.clientId + "\n" + key.username + "\n" + key.tokenUrl))
+	tokenCacheFileName := home + "/.cache/contabo/terraform/token-" + hex.EncodeToString(hash[:8])
 	return &tokenCacheFileName, nil
 }
\ No newline at end of file
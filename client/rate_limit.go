@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// rateLimitWarningThreshold is the number of remaining requests in the current
+// rate limit window below which a warning is logged.
+const rateLimitWarningThreshold = 10
+
+// rateLimitTransport logs a warning once the API reports that only a few
+// requests are left before it starts answering with 429 Too Many Requests.
+type rateLimitTransport struct {
+	base      http.RoundTripper
+	threshold int
+	warn      func(ctx context.Context, msg string)
+}
+
+// NewRateLimitTransport wraps the base transport so that a warning is logged
+// whenever the X-RateLimit-Remaining header of a response drops below the
+// threshold. A non positive threshold disables the warning.
+func NewRateLimitTransport(base http.RoundTripper, threshold int) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if threshold <= 0 {
+		return base
+	}
+
+	return &rateLimitTransport{
+		base:      base,
+		threshold: threshold,
+		warn:      func(ctx context.Context, msg string) { tflog.Warn(ctx, msg) },
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil || remaining >= t.threshold {
+		return resp, nil
+	}
+
+	msg := fmt.Sprintf("approaching the API rate limit, %d requests remaining", remaining)
+	if reset := resp.Header.Get("X-RateLimit-Reset"); reset != "" {
+		msg += fmt.Sprintf(" (X-RateLimit-Reset: %s)", reset)
+	}
+	t.warn(req.Context(), msg)
+
+	return resp, nil
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRateLimitTransportWarnsBelowThreshold(t *testing.T) {
+	remaining := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", remaining)
+		w.Header().Set("X-RateLimit-Reset", "42")
+	}))
+	defer server.Close()
+
+	transport := NewRateLimitTransport(http.DefaultTransport, 10).(*rateLimitTransport)
+	warnings := []string{}
+	transport.warn = func(ctx context.Context, msg string) { warnings = append(warnings, msg) }
+	httpClient := &http.Client{Transport: transport}
+
+	for _, remaining = range []string{"", "100", "10", "9"} {
+		resp, err := httpClient.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(warnings) != 1 {
+		t.Fatalf("expected a single warning below the threshold, got %v", warnings)
+	}
+	if !strings.Contains(warnings[0], "9 requests remaining") || !strings.Contains(warnings[0], "42") {
+		t.Fatalf("expected the warning to contain the remaining requests and the reset, got %q", warnings[0])
+	}
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// sensitiveQueryParams are the query parameters whose values are redacted in
+// the request log.
+var sensitiveQueryParams = map[string]bool{
+	"accesskey":     true,
+	"client_secret": true,
+	"password":      true,
+	"secret":        true,
+	"secretkey":     true,
+	"token":         true,
+}
+
+// requestLogEntry is a line of the request log.
+type requestLogEntry struct {
+	Time       string `json:"time"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Status     int    `json:"status"`
+	RequestId  string `json:"request_id"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// requestLogTransport appends a JSON line per API call to a file, e.g. for
+// auditing the changes of an apply. Headers and bodies are never logged.
+type requestLogTransport struct {
+	base  http.RoundTripper
+	path  string
+	mutex sync.Mutex
+	warn  func(ctx context.Context, msg string)
+}
+
+// NewRequestLogTransport wraps the base transport so that every request is
+// appended to the file at path. An empty path disables the log. The file is
+// created if it does not exist.
+func NewRequestLogTransport(base http.RoundTripper, path string) (http.RoundTripper, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if path == "" {
+		return base, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open request log %v: %v", path, err)
+	}
+	file.Close()
+
+	return &requestLogTransport{
+		base: base,
+		path: path,
+		warn: func(ctx context.Context, msg string) { tflog.Warn(ctx, msg) },
+	}, nil
+}
+
+func (t *requestLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	entry := requestLogEntry{
+		Time:       start.UTC().Format(time.RFC3339),
+		Method:     req.Method,
+		Path:       redactedPath(req),
+		RequestId:  req.Header.Get("x-request-id"),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Status = resp.StatusCode
+	}
+
+	// failing to log must not fail the request
+	if logErr := t.append(entry); logErr != nil {
+		t.warn(req.Context(), fmt.Sprintf("could not write request log %v: %v", t.path, logErr))
+	}
+	return resp, err
+}
+
+func (t *requestLogTransport) append(entry requestLogEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	file, err := os.OpenFile(t.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// redactedPath returns the path and query of the request with the values of
+// sensitive query parameters replaced by REDACTED.
+func redactedPath(req *http.Request) string {
+	query := req.URL.Query()
+	if len(query) == 0 {
+		return req.URL.Path
+	}
+
+	for key := range query {
+		if sensitiveQueryParams[strings.ToLower(key)] {
+			query.Set(key, "REDACTED")
+		}
+	}
+	return req.URL.Path + "?" + query.Encode()
+}
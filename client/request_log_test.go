@@ -0,0 +1,75 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequestLogTransportWritesRedactedLines(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	transport, err := NewRequestLogTransport(http.DefaultTransport, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpClient := &http.Client{Transport: transport}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/v1/secrets?name=db&password=hunter2", nil)
+	req.Header.Set("x-request-id", "req-1")
+	req.Header.Set("Authorization", "Bearer token-1")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	req, _ = http.NewRequest(http.MethodDelete, server.URL+"/v1/private-networks/100", nil)
+	req.Header.Set("x-request-id", "req-2")
+	resp, err = httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "hunter2") || strings.Contains(string(content), "token-1") {
+		t.Fatalf("expected the secrets to be redacted, got %s", content)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a line per request, got %q", lines)
+	}
+	for i, expected := range []requestLogEntry{
+		{Method: http.MethodGet, Path: "/v1/secrets?name=db&password=REDACTED", Status: http.StatusOK, RequestId: "req-1"},
+		{Method: http.MethodDelete, Path: "/v1/private-networks/100", Status: http.StatusNotFound, RequestId: "req-2"},
+	} {
+		var entry requestLogEntry
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Method != expected.Method || entry.Path != expected.Path || entry.Status != expected.Status || entry.RequestId != expected.RequestId {
+			t.Fatalf("expected line %d to be %+v, got %+v", i+1, expected, entry)
+		}
+	}
+}
+
+func TestRequestLogTransportInvalidPath(t *testing.T) {
+	if _, err := NewRequestLogTransport(http.DefaultTransport, filepath.Join(t.TempDir(), "missing", "requests.jsonl")); err == nil {
+		t.Fatal("expected an error for a request log in a missing directory")
+	}
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy tunes how often and how fast failed requests are repeated.
+type RetryPolicy struct {
+	// MaxRetries is the number of times a failed request is repeated, 0 disables retries.
+	MaxRetries int
+	// Delay is the time between two attempts.
+	Delay time.Duration
+	// StatusCodes overrides the status codes of responses which are repeated, empty keeps the defaults.
+	StatusCodes []int
+}
+
+// retryTransport repeats failed requests. Reading requests are idempotent and
+// are repeated on network errors, rate limits and server errors. Mutating
+// requests are only repeated if they were rate limited, as the API did not
+// process them then, so that e.g. an order is never placed twice.
+type retryTransport struct {
+	base      http.RoundTripper
+	reads     RetryPolicy
+	mutations RetryPolicy
+}
+
+// NewRetryTransport wraps the base transport so that reading requests are
+// retried according to the reads policy and mutating requests according to
+// the mutations policy.
+func NewRetryTransport(base http.RoundTripper, reads RetryPolicy, mutations RetryPolicy) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if reads.MaxRetries <= 0 && mutations.MaxRetries <= 0 {
+		return base
+	}
+
+	return &retryTransport{
+		base:      base,
+		reads:     reads,
+		mutations: mutations,
+	}
+}
+
+// noRetriesKey marks the context of requests which the caller retries itself.
+type noRetriesKey struct{}
+
+// WithoutRetries returns a context whose requests are not repeated by the
+// transport, as the caller repeats them already. Otherwise the retries of
+// both layers multiply.
+func WithoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetriesKey{}, true)
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Context().Value(noRetriesKey{}) != nil {
+		return t.base.RoundTrip(req)
+	}
+
+	policy, retryable := t.reads, isRetryableRead
+	if isMutatingRequest(req) {
+		policy, retryable = t.mutations, isRetryableMutation
+	}
+
+	for retry := 0; ; retry++ {
+		attempt := req
+		if retry > 0 && req.Body != nil {
+			// the body of the previous attempt has been consumed
+			if req.GetBody == nil {
+				return t.base.RoundTrip(req)
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt = req.Clone(req.Context())
+			attempt.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attempt)
+		if retry >= policy.MaxRetries || !policy.retries(resp, err, retryable) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(policy.Delay):
+		}
+	}
+}
+
+// retries reports whether the attempt is repeated. The status codes of the
+// policy replace the ones of the default, failures without response are
+// judged by the default alone.
+func (p RetryPolicy) retries(resp *http.Response, err error, retryable func(*http.Response, error) bool) bool {
+	if err != nil || len(p.StatusCodes) == 0 {
+		return retryable(resp, err)
+	}
+	for _, statusCode := range p.StatusCodes {
+		if resp.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func isRetryableRead(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+func isRetryableMutation(resp *http.Response, err error) bool {
+	return err == nil && resp.StatusCode == http.StatusTooManyRequests
+}
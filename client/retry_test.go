@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRetryTransportRetriesReads(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch attempts {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: NewRetryTransport(http.DefaultTransport, RetryPolicy{MaxRetries: 3}, RetryPolicy{})}
+
+	resp, err := httpClient.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || attempts != 3 {
+		t.Fatalf("expected the read to succeed with the third attempt, got %d after %d attempts", resp.StatusCode, attempts)
+	}
+}
+
+func TestRetryTransportGivesUpAfterMaxRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: NewRetryTransport(http.DefaultTransport, RetryPolicy{MaxRetries: 2}, RetryPolicy{})}
+
+	resp, err := httpClient.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway || attempts != 3 {
+		t.Fatalf("expected the last failure after 3 attempts, got %d after %d attempts", resp.StatusCode, attempts)
+	}
+}
+
+func TestRetryTransportLeavesRetriesToTheCaller(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: NewRetryTransport(http.DefaultTransport, RetryPolicy{MaxRetries: 2}, RetryPolicy{})}
+
+	req, _ := http.NewRequestWithContext(WithoutRetries(context.Background()), http.MethodGet, server.URL, nil)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway || attempts != 1 {
+		t.Fatalf("expected a single attempt for a request retried by the caller, got %d after %d attempts", resp.StatusCode, attempts)
+	}
+}
+
+func TestRetryTransportRetriesMutationsOnlyIfRateLimited(t *testing.T) {
+	statuses := []int{}
+	bodies := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(statuses[len(bodies)-1])
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: NewRetryTransport(http.DefaultTransport, RetryPolicy{}, RetryPolicy{MaxRetries: 3})}
+
+	// a rate limited mutation was not processed and is repeated with its body
+	statuses = []int{http.StatusTooManyRequests, http.StatusCreated}
+	resp, err := httpClient.Post(server.URL, "application/json", strings.NewReader(`{"name":"net"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || len(bodies) != 2 || bodies[1] != `{"name":"net"}` {
+		t.Fatalf("expected the rate limited mutation to be repeated with its body, got %d and %q", resp.StatusCode, bodies)
+	}
+
+	// a failed mutation may have been processed and is not repeated
+	statuses, bodies = []int{http.StatusInternalServerError, http.StatusCreated}, nil
+	resp, err = httpClient.Post(server.URL, "application/json", strings.NewReader(`{"name":"net"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError || len(bodies) != 1 {
+		t.Fatalf("expected the failed mutation not to be repeated, got %d after %d attempts", resp.StatusCode, len(bodies))
+	}
+}
+
+func TestRetryTransportRetriesCustomStatusCodes(t *testing.T) {
+	statuses := []int{http.StatusConflict, http.StatusServiceUnavailable}
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statuses[attempts])
+		attempts++
+	}))
+	defer server.Close()
+
+	reads := RetryPolicy{MaxRetries: 3, StatusCodes: []int{http.StatusConflict}}
+	httpClient := &http.Client{Transport: NewRetryTransport(http.DefaultTransport, reads, RetryPolicy{})}
+
+	resp, err := httpClient.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// the 409 is retried, the 503 replaced by the custom codes is not
+	if resp.StatusCode != http.StatusServiceUnavailable || attempts != 2 {
+		t.Fatalf("expected the 409 to be retried and the 503 to be returned, got %d after %d attempts", resp.StatusCode, attempts)
+	}
+}
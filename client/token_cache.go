@@ -0,0 +1,68 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// tokenRefreshAhead is how long before its expiry a cached token is no longer
+// handed out, so that it does not expire in the middle of an apply.
+const tokenRefreshAhead = 5 * time.Minute
+
+// tokenCacheKey identifies the account a token was issued for. Provider
+// configurations sharing a client id may still log in as different users or
+// against different token endpoints.
+type tokenCacheKey struct {
+	clientId string
+	username string
+	tokenUrl string
+}
+
+// tokenCache holds the access tokens of the provider instances configured in
+// this process, keyed by account.
+var tokenCache = struct {
+	sync.Mutex
+	tokens map[tokenCacheKey]*oauth2.Token
+}{tokens: map[tokenCacheKey]*oauth2.Token{}}
+
+// cachedToken returns the cached token of the account if it is still valid
+// for at least tokenRefreshAhead, otherwise nil.
+func cachedToken(key tokenCacheKey) *oauth2.Token {
+	tokenCache.Lock()
+	defer tokenCache.Unlock()
+
+	token, ok := tokenCache.tokens[key]
+	if !ok {
+		return nil
+	}
+	if !token.Expiry.IsZero() && token.Expiry.Before(time.Now().Add(tokenRefreshAhead)) {
+		delete(tokenCache.tokens, key)
+		return nil
+	}
+	return token
+}
+
+func storeCachedToken(key tokenCacheKey, token *oauth2.Token) {
+	tokenCache.Lock()
+	defer tokenCache.Unlock()
+
+	tokenCache.tokens[key] = token
+}
+
+// cachingTokenSource stores the tokens of its source in the token cache, so
+// that tokens refreshed while the provider runs are reused as well.
+type cachingTokenSource struct {
+	key    tokenCacheKey
+	source oauth2.TokenSource
+}
+
+func (s *cachingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	storeCachedToken(s.key, token)
+	return token, nil
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestBearerHttpClientReusesCachedToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tokenRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600,"refresh_token":"eyJhbGciOiJIUzI1NiJ9.eyJleHAiOjB9.c2ln"}`, tokenRequests)
+	}))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := BearerHttpClient(server.Client(), server.URL, "reuse-client", "secret", "user", "password"); err != nil {
+			t.Fatalf("configure %d: unexpected error: %v", i+1, err)
+		}
+	}
+
+	if tokenRequests != 1 {
+		t.Fatalf("expected the second configure to reuse the cached token, got %d token requests", tokenRequests)
+	}
+}
+
+func TestCachedTokenRefreshesAhead(t *testing.T) {
+	validKey := tokenCacheKey{clientId: "valid-client"}
+	expiringKey := tokenCacheKey{clientId: "expiring-client"}
+	storeCachedToken(validKey, &oauth2.Token{AccessToken: "valid", Expiry: time.Now().Add(time.Hour)})
+	storeCachedToken(expiringKey, &oauth2.Token{AccessToken: "expiring", Expiry: time.Now().Add(time.Minute)})
+
+	if token := cachedToken(validKey); token == nil || token.AccessToken != "valid" {
+		t.Fatalf("expected the valid token to be reused, got %v", token)
+	}
+	if token := cachedToken(expiringKey); token != nil {
+		t.Fatalf("expected a token expiring within %s not to be reused, got %v", tokenRefreshAhead, token)
+	}
+	if token := cachedToken(tokenCacheKey{clientId: "unknown-client"}); token != nil {
+		t.Fatalf("expected no token for an unknown client id, got %v", token)
+	}
+}
+
+func TestBearerHttpClientKeepsTokensPerUser(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tokenRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600,"refresh_token":"eyJhbGciOiJIUzI1NiJ9.eyJleHAiOjB9.c2ln"}`, tokenRequests)
+	}))
+	defer server.Close()
+
+	for _, username := range []string{"first-user", "second-user"} {
+		if _, err := BearerHttpClient(server.Client(), server.URL, "shared-client", "secret", username, "password"); err != nil {
+			t.Fatalf("configure %s: unexpected error: %v", username, err)
+		}
+	}
+
+	if tokenRequests != 2 {
+		t.Fatalf("expected a token per user of the client id, got %d token requests", tokenRequests)
+	}
+	first := cachedToken(tokenCacheKey{clientId: "shared-client", username: "first-user", tokenUrl: server.URL})
+	second := cachedToken(tokenCacheKey{clientId: "shared-client", username: "second-user", tokenUrl: server.URL})
+	if first == nil || second == nil || first.AccessToken == second.AccessToken {
+		t.Fatalf("expected different tokens per user, got %v and %v", first, second)
+	}
+}
+
+func TestBearerHttpClientCachesRefreshedToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/token" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := r.ParseForm(); err == nil && r.PostForm.Get("grant_type") == "refresh_token" {
+			fmt.Fprint(w, `{"access_token":"refreshed","token_type":"Bearer","expires_in":3600,"refresh_token":"eyJhbGciOiJIUzI1NiJ9.eyJleHAiOjB9.c2ln"}`)
+			return
+		}
+		// already expired, so that the first request refreshes it
+		fmt.Fprint(w, `{"access_token":"initial","token_type":"Bearer","expires_in":1,"refresh_token":"eyJhbGciOiJIUzI1NiJ9.eyJleHAiOjB9.c2ln"}`)
+	}))
+	defer server.Close()
+
+	httpClient, err := BearerHttpClient(server.Client(), server.URL+"/token", "refresh-client", "secret", "user", "password")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res, err := httpClient.Get(server.URL + "/v1/compute/instances")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res.Body.Close()
+
+	token := cachedToken(tokenCacheKey{clientId: "refresh-client", username: "user", tokenUrl: server.URL + "/token"})
+	if token == nil || token.AccessToken != "refreshed" {
+		t.Fatalf("expected the refreshed token to be cached, got %v", token)
+	}
+}
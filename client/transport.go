@@ -0,0 +1,81 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NewTransport builds the transport used for the API and the token requests.
+// Without further options it behaves like the default transport, it honors the
+// HTTP_PROXY and HTTPS_PROXY environment variables and trusts the system roots.
+func NewTransport(options Options) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if options.ProxyURL != "" {
+		proxyUrl, err := parseProxyUrl(options.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+
+	if options.TLSCACert == "" && !options.TLSInsecure {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: options.TLSInsecure,
+	}
+
+	if options.TLSCACert != "" {
+		rootCAs, err := loadCACertPool(options.TLSCACert)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// loadCACertPool adds the CA bundle, given as PEM or as path to a PEM file, to
+// the system roots.
+func loadCACertPool(caCert string) (*x509.CertPool, error) {
+	pemCerts := []byte(caCert)
+	if !strings.Contains(caCert, "-----BEGIN") {
+		var err error
+		pemCerts, err = ioutil.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA certificate file %v: %v", caCert, err)
+		}
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil || rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+
+	if !rootCAs.AppendCertsFromPEM(pemCerts) {
+		return nil, fmt.Errorf("no valid PEM encoded certificate found in the CA certificate")
+	}
+	return rootCAs, nil
+}
+
+func parseProxyUrl(rawProxyUrl string) (*url.URL, error) {
+	proxyUrl, err := url.Parse(rawProxyUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url %v: %v", rawProxyUrl, err)
+	}
+
+	switch proxyUrl.Scheme {
+	case "http", "https", "socks5":
+		return proxyUrl, nil
+	}
+	return nil, fmt.Errorf("invalid proxy url %v: scheme must be http, https or socks5", rawProxyUrl)
+}
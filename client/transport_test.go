@@ -0,0 +1,109 @@
+package client
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testServerCACert(server *httptest.Server) string {
+	return string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}))
+}
+
+func TestTransportLoadsCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(caFile, []byte(testServerCACert(server)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, caCert := range map[string]string{"pem": testServerCACert(server), "path": caFile} {
+		transport, err := NewTransport(Options{TLSCACert: caCert})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			t.Fatalf("%s: expected the custom CA to be trusted, got %v", name, err)
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestTransportDefaultsToSystemRoots(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	transport, err := NewTransport(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (&http.Client{Transport: transport}).Get(server.URL); err == nil {
+		t.Fatal("expected the self signed test certificate not to be trusted")
+	}
+}
+
+func TestTransportInsecure(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	transport, err := NewTransport(Options{TLSInsecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the certificate check to be skipped, got %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestTransportInvalidCA(t *testing.T) {
+	if _, err := NewTransport(Options{TLSCACert: filepath.Join(os.TempDir(), "does-not-exist.pem")}); err == nil {
+		t.Fatal("expected an error for a missing CA file")
+	}
+	if _, err := NewTransport(Options{TLSCACert: "-----BEGIN CERTIFICATE-----\ninvalid"}); err == nil {
+		t.Fatal("expected an error for an invalid CA certificate")
+	}
+}
+
+func TestTransportRoutesThroughProxy(t *testing.T) {
+	proxiedUrls := []string{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedUrls = append(proxiedUrls, r.URL.String())
+	}))
+	defer proxy.Close()
+
+	transport, err := NewTransport(Options{ProxyURL: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := (&http.Client{Transport: transport}).Get("http://api.contabo.invalid/v1/compute/instances")
+	if err != nil {
+		t.Fatalf("expected the request to reach the proxy, got %v", err)
+	}
+	resp.Body.Close()
+
+	if len(proxiedUrls) != 1 || proxiedUrls[0] != "http://api.contabo.invalid/v1/compute/instances" {
+		t.Fatalf("expected the request to be routed through the proxy, got %v", proxiedUrls)
+	}
+}
+
+func TestTransportInvalidProxy(t *testing.T) {
+	if _, err := NewTransport(Options{ProxyURL: "ftp://proxy.example.com"}); err == nil {
+		t.Fatal("expected an error for an unsupported proxy scheme")
+	}
+}
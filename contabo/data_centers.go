@@ -0,0 +1,117 @@
+package contabo
+
+import (
+	"context"
+	"net/url"
+
+	"contabo.com/openapi"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	uuid "github.com/satori/go.uuid"
+)
+
+// dataCenterListPageSize covers all data centers of Contabo with one request.
+const dataCenterListPageSize = 100
+
+func s3UrlSchema(resourceName string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "S3 URL of the Object Storage in the data center of the " + resourceName + ", so that it must not be hard coded.",
+	}
+}
+
+// s3Endpoint returns the host name of the S3 URL, e.g. eu2.contabostorage.com,
+// as S3 clients like the aws cli or boto take it as endpoint. The S3 URLs are
+// assigned per data center, so the host name also identifies the region. It
+// returns an empty string for an invalid or empty URL.
+func s3Endpoint(s3Url string) string {
+	parsed, err := url.Parse(s3Url)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
+
+// listDataCenters fetches all data centers of Contabo.
+func listDataCenters(
+	ctx context.Context,
+	client *openapi.APIClient,
+) ([]openapi.DataCenterResponse, error) {
+	dataCenters := []openapi.DataCenterResponse{}
+
+	err := paginate(dataCenterListPageSize, func(page int64) (int, int64, error) {
+		res, httpResp, err := client.DataCentersApi.
+			RetrieveDataCenterList(ctx).
+			XRequestId(uuid.NewV4().String()).
+			Page(page).
+			Size(dataCenterListPageSize).
+			Execute()
+		if err != nil {
+			return 0, 0, NewApiError(httpResp, err)
+		}
+
+		dataCenters = append(dataCenters, res.Data...)
+		return len(res.Data), int64(res.Pagination.TotalPages), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dataCenters, nil
+}
+
+// findDataCenter looks up a data center by its name, as it is returned for
+// instances and private networks. It returns nil if there is no such data
+// center.
+func findDataCenter(
+	ctx context.Context,
+	client *openapi.APIClient,
+	name string,
+) (*openapi.DataCenterResponse, error) {
+	dataCenters, err := listDataCenters(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dataCenter := range dataCenters {
+		if dataCenter.Name == name {
+			return &dataCenter, nil
+		}
+	}
+	return nil, nil
+}
+
+// hasCapability reports whether the data center offers the capability, e.g.
+// Object-Storage.
+func hasCapability(dataCenter openapi.DataCenterResponse, capability string) bool {
+	for _, c := range dataCenter.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// AddDataCenterS3UrlToData sets s3_url from the data center the resource is
+// located in.
+func AddDataCenterS3UrlToData(
+	ctx context.Context,
+	client *openapi.APIClient,
+	dataCenterName string,
+	d *schema.ResourceData,
+	diags diag.Diagnostics,
+) diag.Diagnostics {
+	dataCenter, err := findDataCenter(ctx, client, dataCenterName)
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+
+	s3Url := ""
+	if dataCenter != nil {
+		s3Url = dataCenter.S3Url
+	}
+	if err := d.Set("s3_url", s3Url); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
@@ -0,0 +1,69 @@
+package contabo
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	uuid "github.com/satori/go.uuid"
+)
+
+func dataSourceAccount() *schema.Resource {
+	return &schema.Resource{
+		Description: "Provides the details of the account the provider is authenticated with. As it needs valid credentials it can also be used to check the provider configuration.",
+		ReadContext: dataSourceAccountRead,
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The identifier of the API client.",
+			},
+			"client_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The oauth2 client id the provider is authenticated with.",
+			},
+			"customer_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Your customer number.",
+			},
+			"tenant_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Your customer tenant Id.",
+			},
+		},
+	}
+}
+
+func dataSourceAccountRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	client := m.(*providerMeta).client
+
+	res, httpResp, err := client.UsersApi.
+		RetrieveUserClient(ctx).
+		XRequestId(uuid.NewV4().String()).
+		Execute()
+
+	if err != nil {
+		return HandleResponseErrors(diags, httpResp)
+	} else if len(res.Data) != 1 {
+		return MultipleDataObjectsError(diags)
+	}
+
+	account := res.Data[0]
+	d.SetId(account.Id)
+
+	if err := d.Set("client_id", account.ClientId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("customer_id", account.CustomerId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("tenant_id", account.TenantId); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diags
+}
@@ -0,0 +1,38 @@
+package contabo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceAccountRead(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/users/client" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		writeJson(w, http.StatusOK, `{"data":[{"id":"c4a2f7a6-31a4-4c0e-9b5c-0d5b2c1e8d11","clientId":"DE-54321","customerId":"54321","tenantId":"DE","secret":""}],"_links":{"self":"/"}}`)
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceAccount().Schema, map[string]interface{}{})
+
+	if diags := dataSourceAccountRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	expected := map[string]string{
+		"client_id":   "DE-54321",
+		"customer_id": "54321",
+		"tenant_id":   "DE",
+	}
+	for key, value := range expected {
+		if got := d.Get(key).(string); got != value {
+			t.Errorf("expected %s to be %q, got %q", key, value, got)
+		}
+	}
+	if d.Id() != "c4a2f7a6-31a4-4c0e-9b5c-0d5b2c1e8d11" {
+		t.Errorf("unexpected id %q", d.Id())
+	}
+}
@@ -3,7 +3,6 @@ package contabo
 import (
 	"context"
 
-	apiClient "contabo.com/openapi"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	uuid "github.com/satori/go.uuid"
@@ -81,7 +80,7 @@ func dataSourceImage() *schema.Resource {
 
 func dataSourceImageRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*apiClient.APIClient)
+	client := m.(*providerMeta).client
 
 	imageId := d.Get("id").(string)
 
@@ -4,7 +4,7 @@ import (
 	"context"
 	"strconv"
 
-	apiClient "contabo.com/openapi"
+	"contabo.com/openapi"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	uuid "github.com/satori/go.uuid"
@@ -153,6 +153,12 @@ func dataSourceInstance() *schema.Resource {
 				Computed:    true,
 				Description: "The date on which the instance will be cancelled.",
 			},
+			"s3_url": s3UrlSchema("instance"),
+			"healthy": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the instance is `running` without an error message, e.g. as a single gate for dependent modules. The API reports neither the cloud-init progress nor the reachability of an instance, so they are not taken into account.",
+			},
 			"status": {
 				Type:        schema.TypeString,
 				Computed:    true,
@@ -211,6 +217,30 @@ func dataSourceInstance() *schema.Resource {
 				Computed:    true,
 				Description: "Initial contract period in months. Available periods are: 1, 3, 6 and 12 months. The default setting is 1 month.",
 			},
+			"private_networks": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The Private Networks the instance is assigned to.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"private_network_id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The identifier of the Private Network.",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the Private Network.",
+						},
+						"private_ip": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The private IPv4 address of the instance in the Private Network.",
+						},
+					},
+				},
+			},
 			"additional_ips_v4": {
 				Type:        schema.TypeList,
 				Computed:    true,
@@ -245,7 +275,7 @@ func dataSourceInstanceRead(
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*apiClient.APIClient)
+	client := m.(*providerMeta).client
 
 	var instanceId int64
 	var err error
@@ -271,9 +301,68 @@ func dataSourceInstanceRead(
 
 	d.SetId(strconv.Itoa(int(res.Data[0].InstanceId)))
 
-	return AddInstanceToData(
+	diags = AddInstanceToData(
 		res.Data[0],
 		d,
 		diags,
 	)
+	if diags.HasError() {
+		return diags
+	}
+
+	// the instance itself does not list its Private Networks
+	privateNetworks, err := listInstancePrivateNetworks(ctx, client, instanceId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("private_networks", privateNetworks); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return AddDataCenterS3UrlToData(ctx, client, res.Data[0].DataCenter, d, diags)
+}
+
+// listInstancePrivateNetworks lists the Private Networks the instance is
+// assigned to along with its private IP in each of them.
+func listInstancePrivateNetworks(
+	ctx context.Context,
+	client *openapi.APIClient,
+	instanceId int64,
+) ([]map[string]interface{}, error) {
+	privateNetworks := []map[string]interface{}{}
+
+	err := paginate(privateNetworkListPageSize, func(page int64) (int, int64, error) {
+		res, httpResp, err := client.PrivateNetworksApi.
+			RetrievePrivateNetworkList(ctx).
+			XRequestId(uuid.NewV4().String()).
+			Page(page).
+			Size(privateNetworkListPageSize).
+			InstanceIds(strconv.FormatInt(instanceId, 10)).
+			Execute()
+		if err != nil {
+			return 0, 0, NewApiError(httpResp, err)
+		}
+
+		for _, privateNetwork := range res.Data {
+			for _, instance := range privateNetwork.Instances {
+				if instance.InstanceId != instanceId {
+					continue
+				}
+				privateIp := ""
+				if len(instance.PrivateIpConfig.V4) > 0 {
+					privateIp = instance.PrivateIpConfig.V4[0].Ip
+				}
+				privateNetworks = append(privateNetworks, map[string]interface{}{
+					"private_network_id": privateNetwork.PrivateNetworkId,
+					"name":               privateNetwork.Name,
+					"private_ip":         privateIp,
+				})
+			}
+		}
+		return len(res.Data), int64(res.Pagination.TotalPages), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return privateNetworks, nil
 }
@@ -0,0 +1,52 @@
+package contabo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestInstanceDataSourceListsPrivateNetworks(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/10":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(10)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks":
+			if instanceIds := r.URL.Query().Get("instanceIds"); instanceIds != "10" {
+				t.Fatalf("expected the Private Networks to be filtered by instance 10, got %q", instanceIds)
+			}
+			writeJson(w, http.StatusOK, listBody(
+				testPrivateNetworkJson(100, "backend", testPrivateNetworkInstanceJson(10, "ok"), testPrivateNetworkInstanceJson(20, "ok"))+","+
+					testPrivateNetworkJson(200, "storage", testPrivateNetworkInstanceJson(10, "ok")), 2))
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceInstance().Schema, map[string]interface{}{
+		"id": "10",
+	})
+
+	if diags := dataSourceInstanceRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	privateNetworks := d.Get("private_networks").([]interface{})
+	if len(privateNetworks) != 2 {
+		t.Fatalf("expected the instance to be listed in 2 Private Networks, got %v", privateNetworks)
+	}
+	for i, expected := range []struct {
+		id   int
+		name string
+	}{{100, "backend"}, {200, "storage"}} {
+		privateNetwork := privateNetworks[i].(map[string]interface{})
+		if privateNetwork["private_network_id"] != expected.id || privateNetwork["name"] != expected.name {
+			t.Fatalf("expected Private Network %d (%s), got %v", expected.id, expected.name, privateNetwork)
+		}
+		if privateNetwork["private_ip"] != "10.0.0.10" {
+			t.Fatalf("expected the private ip of instance 10, got %v", privateNetwork["private_ip"])
+		}
+	}
+}
@@ -3,7 +3,6 @@ package contabo
 import (
 	"context"
 
-	apiClient "contabo.com/openapi"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	uuid "github.com/satori/go.uuid"
@@ -34,6 +33,11 @@ func dataSourceObjectStorage() *schema.Resource {
 				Computed:    true,
 				Description: "S3 URL to connect to your S3 compatible Object Storage.",
 			},
+			"s3_endpoint": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Host name of the S3 URL, e.g. `eu2.contabostorage.com`, to configure S3 clients like the `aws` cli or boto for the region of the Object Storage.",
+			},
 			"s3_tenant_id": {
 				Type:        schema.TypeString,
 				Computed:    true,
@@ -86,11 +90,26 @@ func dataSourceObjectStorage() *schema.Resource {
 				Computed:    true,
 				Description: "Data center the object storage is located in.",
 			},
+			"display_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The display name of the Object Storage.",
+			},
 			"region": {
 				Type:        schema.TypeString,
 				Computed:    true,
 				Description: "Region where the Object Storage should be located. Default region is the EU. Following regions are available: `EU`,`US-central`, `SIN`.",
 			},
+			"used_space_tb": {
+				Type:        schema.TypeFloat,
+				Computed:    true,
+				Description: "Currently used space of the Object Storage in terabyte.",
+			},
+			"used_space_percentage": {
+				Type:        schema.TypeFloat,
+				Computed:    true,
+				Description: "Currently used space of the Object Storage in percent of the purchased space.",
+			},
 			"total_purchased_space_tb": {
 				Type:        schema.TypeFloat,
 				Computed:    true,
@@ -106,7 +125,7 @@ func dataSourceObjectStorageRead(
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*apiClient.APIClient)
+	client := m.(*providerMeta).client
 
 	var objectStorageId string
 	var err error
@@ -130,9 +149,14 @@ func dataSourceObjectStorageRead(
 
 	d.SetId(res.Data[0].ObjectStorageId)
 
-	return AddObjectStorageToData(
+	diags = AddObjectStorageToData(
 		res.Data[0],
 		d,
 		diags,
 	)
+	if diags.HasError() {
+		return diags
+	}
+
+	return AddObjectStorageStatsToData(ctx, client, objectStorageId, d, diags)
 }
@@ -0,0 +1,110 @@
+package contabo
+
+import (
+	"context"
+	"strconv"
+
+	"contabo.com/openapi"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	uuid "github.com/satori/go.uuid"
+)
+
+func dataSourceObjectStorageCredentials() *schema.Resource {
+	return &schema.Resource{
+		Description: "Fetches the S3 credentials of a user for an Object Storage on demand, e.g. to configure an S3 client. The access and secret key are marked as sensitive, so Terraform does not show them, but like every data source attribute they are stored in plain text in the state. Keep the state encrypted or reference the credentials only where needed.",
+		ReadContext: dataSourceObjectStorageCredentialsRead,
+		Schema: map[string]*schema.Schema{
+			"user_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The identifier of the user the credentials belong to.",
+			},
+			"object_storage_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The identifier of the Object Storage.",
+			},
+			"credential_id": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "The identifier of the credentials. If not set, the first credentials of the user for the Object Storage are used.",
+			},
+			"display_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The display name of the Object Storage.",
+			},
+			"region": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The region of the Object Storage.",
+			},
+			"access_key": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The S3 access key.",
+			},
+			"secret_key": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The S3 secret key.",
+			},
+		},
+	}
+}
+
+func dataSourceObjectStorageCredentialsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	client := m.(*providerMeta).client
+
+	objectStorageId := d.Get("object_storage_id").(string)
+	res, httpResp, err := client.UsersApi.
+		ListObjectStorageCredentials(ctx, d.Get("user_id").(string)).
+		XRequestId(uuid.NewV4().String()).
+		ObjectStorageId(objectStorageId).
+		Execute()
+
+	if err != nil {
+		return HandleResponseErrors(diags, httpResp)
+	}
+
+	credential := findObjectStorageCredential(res.Data, int64(d.Get("credential_id").(int)))
+	if credential == nil {
+		return diag.Errorf("no credentials found for Object Storage %s", objectStorageId)
+	}
+
+	d.SetId(strconv.Itoa(int(credential.CredentialId)))
+
+	if err := d.Set("credential_id", credential.CredentialId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("display_name", credential.DisplayName); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("region", credential.Region); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("access_key", credential.AccessKey); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("secret_key", credential.SecretKey); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diags
+}
+
+// findObjectStorageCredential returns the credentials with the given id, or
+// the first ones if credentialId is 0.
+func findObjectStorageCredential(credentials []openapi.CredentialData, credentialId int64) *openapi.CredentialData {
+	for i, credential := range credentials {
+		if credentialId == 0 || credential.CredentialId == credentialId {
+			return &credentials[i]
+		}
+	}
+	return nil
+}
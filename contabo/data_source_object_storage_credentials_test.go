@@ -0,0 +1,43 @@
+package contabo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestObjectStorageCredentialsAreSensitive(t *testing.T) {
+	for _, key := range []string{"access_key", "secret_key"} {
+		if !dataSourceObjectStorageCredentials().Schema[key].Sensitive {
+			t.Errorf("expected %s to be sensitive", key)
+		}
+	}
+}
+
+func TestObjectStorageCredentialsRead(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/users/u1/object-storages/credentials" || r.URL.Query().Get("objectStorageId") != "os1" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		writeJson(w, http.StatusOK, listBody(`
+			{"tenantId":"DE","customerId":"54321","accessKey":"AK1","secretKey":"SK1","objectStorageId":"os1",
+			"credentialId":1,"displayName":"storage","region":"EU"},
+			{"tenantId":"DE","customerId":"54321","accessKey":"AK2","secretKey":"SK2","objectStorageId":"os1",
+			"credentialId":2,"displayName":"storage","region":"EU"}`, 2))
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceObjectStorageCredentials().Schema, map[string]interface{}{
+		"user_id":           "u1",
+		"object_storage_id": "os1",
+		"credential_id":     2,
+	})
+
+	if diags := dataSourceObjectStorageCredentialsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "2" || d.Get("access_key") != "AK2" || d.Get("secret_key") != "SK2" {
+		t.Fatalf("expected credentials 2, got %s %v %v", d.Id(), d.Get("access_key"), d.Get("secret_key"))
+	}
+}
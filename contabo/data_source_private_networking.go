@@ -4,7 +4,6 @@ import (
 	"context"
 	"strconv"
 
-	apiClient "contabo.com/openapi"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	uuid "github.com/satori/go.uuid"
@@ -21,17 +20,37 @@ func dataSourcePrivateNetwork() *schema.Resource {
 				Computed:    true,
 				Description: "The creation date of the Private Network.",
 			},
+			"created_by": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The customer number of the account which created the Private Network. The API does not tell which user of the account created it.",
+			},
 			"updated_at": {
 				Type:        schema.TypeString,
 				Optional:    true,
 				Computed:    true,
 				Description: "Time of the last update of the private network.",
 			},
+			"created_date_rfc3339": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The creation date of the Private Network in RFC 3339 format, e.g. `2022-01-01T00:00:00Z`, which unlike `created_date` can be parsed by `timeadd` and other functions.",
+			},
+			"updated_at_rfc3339": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Time of the last update of the Private Network in RFC 3339 format, like `updated_at`.",
+			},
 			"id": {
 				Type:        schema.TypeString,
 				Computed:    true,
 				Description: "The identifier of the Private Network. Use it to manage it!",
 			},
+			"private_network_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The identifier of the Private Network as a number, e.g. for scripts calling the API.",
+			},
 			"name": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -54,7 +73,7 @@ func dataSourcePrivateNetwork() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"instance_id": {
-							Type:        schema.TypeString,
+							Type:        schema.TypeInt,
 							Computed:    true,
 							Description: "The identifier of the compute instance.",
 						},
@@ -106,6 +125,26 @@ func dataSourcePrivateNetwork() *schema.Resource {
 							Computed:    true,
 							Description: "State of the instance in the Private Network. The status can be one of 'ok', 'restart', 'reinstall', 'reinstallation failed', 'installing'",
 						},
+						"image_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The identifier of the image the instance runs. Only read if `include_instance_details` is set.",
+						},
+						"os_type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The type of the operating system the instance runs, e.g. `Linux` or `Windows`. Only read if `include_instance_details` is set.",
+						},
+						"region": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The region the instance is located in, e.g. `EU`. An instance of another region than the Private Network can not reach it. Only read if `include_instance_details` is set.",
+						},
+						"has_private_networking_addon": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the instance has the private networking add-on, which is required to assign it to a Private Network. Only read if `include_instance_details` is set.",
+						},
 						"error_message": {
 							Type:        schema.TypeString,
 							Computed:    true,
@@ -114,6 +153,25 @@ func dataSourcePrivateNetwork() *schema.Resource {
 					},
 				},
 			},
+			"s3_url": s3UrlSchema("Private Network"),
+			"include_instance_details": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, the details of the instances which the Private Network does not report, i.e. `image_id`, `os_type`, `region` and `has_private_networking_addon`, are read. This requires reading every instance and is therefore disabled by default.",
+			},
+			"include_tags": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, the ids of the tags assigned to the Private Network are read into `tags`. This requires listing the assignments of all tags and is therefore disabled by default.",
+			},
+			"tags": {
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Computed:    true,
+				Description: "Ids of the tags assigned to the Private Network. Only read if `include_tags` is set.",
+			},
 			"region": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -141,13 +199,43 @@ func dataSourcePrivateNetwork() *schema.Resource {
 				Computed:    true,
 				Description: "The cidr range of the Private Network.",
 			},
+			"cidr_first_ip": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The first usable host address of the cidr range. For a /31 range both addresses are usable, a /32 range is a single host. Empty as long as no cidr range is assigned.",
+			},
+			"cidr_last_ip": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The last usable host address of the cidr range, i.e. the one before the broadcast address. Empty as long as no cidr range is assigned.",
+			},
+			"cidr_host_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The number of usable host addresses of the cidr range, e.g. `1022` for a /22 range. `0` as long as no cidr range is assigned.",
+			},
+			"network_gateway": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The gateway of the Private Network, i.e. the first usable address of its cidr range. Empty as long as no cidr range is assigned.",
+			},
+			"ready": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the Private Network has a cidr range assigned and all its instances report the status `ok`.",
+			},
+			"members_ready_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The number of instances of the Private Network which report the status `ok`, e.g. for a progress gauge along with the length of `instances`.",
+			},
 		},
 	}
 }
 
 func dataSourcePrivateNetworkRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*apiClient.APIClient)
+	client := m.(*providerMeta).client
 
 	var privateNetworktId int64
 	var err error
@@ -173,5 +261,29 @@ func dataSourcePrivateNetworkRead(ctx context.Context, d *schema.ResourceData, m
 
 	d.SetId(strconv.Itoa(int(res.Data[0].PrivateNetworkId)))
 
-	return AddPrivateNetworkToData(res.Data[0], d, diags)
+	diags = AddPrivateNetworkToData(res.Data[0], d, diags)
+	if diags.HasError() {
+		return diags
+	}
+
+	if d.Get("include_instance_details").(bool) {
+		diags = AddInstanceDetailsToData(ctx, client, d, diags)
+		if diags.HasError() {
+			return diags
+		}
+	}
+
+	diags = AddDataCenterS3UrlToData(ctx, client, res.Data[0].DataCenter, d, diags)
+	if diags.HasError() || !d.Get("include_tags").(bool) {
+		return diags
+	}
+
+	tags, err := readAssignedTags(ctx, client, privateNetworkTagResourceType, d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("tags", tags); err != nil {
+		return diag.FromErr(err)
+	}
+	return diags
 }
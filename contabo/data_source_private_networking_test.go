@@ -0,0 +1,82 @@
+package contabo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestPrivateNetworkDataSourceMirrorsResource(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "observed",
+				testPrivateNetworkInstanceJson(200, "ok"),
+				testPrivateNetworkInstanceJson(100, "ok"))+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(100)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/200":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceJson(200, "running")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/tags":
+			writeJson(w, http.StatusOK, listBody(`
+				{"tenantId":"DE","customerId":"54321","tagId":7,"name":"production","color":"#0A78C3"},
+				{"tenantId":"DE","customerId":"54321","tagId":8,"name":"staging","color":"#0A78C3"}`, 2))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/tags/7/assignments":
+			writeJson(w, http.StatusOK, listBody(`
+				{"tenantId":"DE","customerId":"54321","tagId":7,"tagName":"production",
+				"resourceType":"private-network","resourceId":"100","resourceName":"observed"}`, 1))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/tags/8/assignments":
+			writeJson(w, http.StatusOK, listBody(`
+				{"tenantId":"DE","customerId":"54321","tagId":8,"tagName":"staging",
+				"resourceType":"private-network","resourceId":"101","resourceName":"other"}`, 1))
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	resourceData := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"tags":                     []interface{}{7},
+		"include_instance_details": true,
+	})
+	resourceData.SetId("100")
+	if diags := resourcePrivateNetworkRead(context.Background(), resourceData, meta); diags.HasError() {
+		t.Fatalf("unexpected resource error: %v", diags)
+	}
+
+	dataSourceData := schema.TestResourceDataRaw(t, dataSourcePrivateNetwork().Schema, map[string]interface{}{
+		"include_tags":             true,
+		"include_instance_details": true,
+	})
+	dataSourceData.SetId("100")
+	if diags := dataSourcePrivateNetworkRead(context.Background(), dataSourceData, meta); diags.HasError() {
+		t.Fatalf("unexpected data source error: %v", diags)
+	}
+
+	// arguments which only make sense for the resource
+	resourceOnly := map[string]bool{
+		"_debug_last_response":  true,
+		"adopt_existing":        true,
+		"allow_empty":           true,
+		"assign_on_create_only": true,
+		"check_addons":          true,
+		"deletion_protection":   true,
+		"ignore_instances":      true,
+		"instance_order":        true,
+		"wait_for_deletion":     true,
+		"wait_for_private_ip":   true,
+		"last_request_id":       true,
+	}
+
+	resourceAttributes := resourceData.State().Attributes
+	dataSourceAttributes := dataSourceData.State().Attributes
+	for key, value := range resourceAttributes {
+		if resourceOnly[key] {
+			continue
+		}
+		if dataSourceAttributes[key] != value {
+			t.Errorf("expected %s to be %q in the data source, got %q", key, value, dataSourceAttributes[key])
+		}
+	}
+}
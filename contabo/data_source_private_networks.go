@@ -0,0 +1,276 @@
+package contabo
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	apiClient "contabo.com/openapi"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	uuid "github.com/satori/go.uuid"
+)
+
+// privateNetworkListPageSize is the page size used while listing Private Networks.
+const privateNetworkListPageSize = 100
+
+func dataSourcePrivateNetworks() *schema.Resource {
+	return &schema.Resource{
+		Description: "Lists all Contabo [Private Networks](https://api.contabo.com/#tag/Private-Networks), optionally filtered by name, region or available IPs.",
+		ReadContext: dataSourcePrivateNetworksRead,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only list Private Networks whose name contains this value.",
+			},
+			"region": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only list Private Networks located in this region, e.g. `EU`.",
+			},
+			"min_available_ips": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Only list Private Networks with at least this number of available IPs.",
+			},
+			"max_available_ips": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Only list Private Networks with at most this number of available IPs, e.g. to find the ones running low on IPs.",
+			},
+			"group_by_region": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, `regions` groups the listed Private Networks by their region.",
+			},
+			"private_networks": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The listed Private Networks.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The identifier of the Private Network.",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the Private Network.",
+						},
+						"description": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The description of the Private Network.",
+						},
+						"region": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The region where the Private Network is located.",
+						},
+						"data_center": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The specific data center where the Private Network is located.",
+						},
+						"cidr": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The cidr range of the Private Network.",
+						},
+						"available_ips": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The totality of available IPs in the Private Network.",
+						},
+						"created_date": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The creation date of the Private Network.",
+						},
+						"instance_ids": {
+							Type:        schema.TypeList,
+							Elem:        &schema.Schema{Type: schema.TypeInt},
+							Computed:    true,
+							Description: "The ids of the instances in the Private Network.",
+						},
+					},
+				},
+			},
+			"regions": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The listed Private Networks grouped by region, only set if `group_by_region` is set. Use e.g. `{ for r in regions : r.region => r.private_network_ids }` to get a map keyed by region.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"region": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The region of the group.",
+						},
+						"private_network_ids": {
+							Type:        schema.TypeList,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Computed:    true,
+							Description: "The identifiers of the Private Networks located in the region.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourcePrivateNetworksRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	client := m.(*providerMeta).client
+
+	name := d.Get("name").(string)
+	region := d.Get("region").(string)
+
+	privateNetworks, err := listPrivateNetworks(ctx, client, name, region)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	// the API cannot filter by available IPs
+	privateNetworks = filterPrivateNetworksByAvailableIps(
+		privateNetworks,
+		d.Get("min_available_ips").(int),
+		d.Get("max_available_ips").(int),
+	)
+
+	if err := d.Set("private_networks", buildPrivateNetworkList(privateNetworks)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	regions := []map[string]interface{}{}
+	if d.Get("group_by_region").(bool) {
+		regions = groupPrivateNetworksByRegion(privateNetworks)
+	}
+	if err := d.Set("regions", regions); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
+
+	return diags
+}
+
+// listPrivateNetworks fetches all pages of Private Networks, optionally
+// filtered by name and region.
+func listPrivateNetworks(
+	ctx context.Context,
+	client *apiClient.APIClient,
+	name string,
+	region string,
+) ([]apiClient.PrivateNetworkResponse, error) {
+	privateNetworks := []apiClient.PrivateNetworkResponse{}
+
+	err := paginate(privateNetworkListPageSize, func(page int64) (int, int64, error) {
+		request := client.PrivateNetworksApi.
+			RetrievePrivateNetworkList(ctx).
+			XRequestId(uuid.NewV4().String()).
+			Page(page).
+			Size(privateNetworkListPageSize)
+		if name != "" {
+			request = request.Name(name)
+		}
+		if region != "" {
+			request = request.Region(region)
+		}
+
+		res, httpResp, err := request.Execute()
+		if err != nil {
+			return 0, 0, NewApiError(httpResp, err)
+		}
+
+		privateNetworks = append(privateNetworks, res.Data...)
+		return len(res.Data), int64(res.Pagination.TotalPages), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return privateNetworks, nil
+}
+
+// filterPrivateNetworksByAvailableIps keeps the Private Networks with at least
+// minAvailableIps and, unless it is 0, at most maxAvailableIps available IPs.
+func filterPrivateNetworksByAvailableIps(
+	privateNetworks []apiClient.PrivateNetworkResponse,
+	minAvailableIps int,
+	maxAvailableIps int,
+) []apiClient.PrivateNetworkResponse {
+	filtered := []apiClient.PrivateNetworkResponse{}
+	for _, privateNetwork := range privateNetworks {
+		availableIps := int(privateNetwork.AvailableIps)
+		if availableIps < minAvailableIps {
+			continue
+		}
+		if maxAvailableIps > 0 && availableIps > maxAvailableIps {
+			continue
+		}
+		filtered = append(filtered, privateNetwork)
+	}
+	return filtered
+}
+
+func buildPrivateNetworkList(privateNetworks []apiClient.PrivateNetworkResponse) []map[string]interface{} {
+	list := []map[string]interface{}{}
+
+	for _, privateNetwork := range privateNetworks {
+		instanceIds := []int64{}
+		for _, instance := range privateNetwork.Instances {
+			instanceIds = append(instanceIds, instance.InstanceId)
+		}
+		sort.Slice(instanceIds, func(i, j int) bool { return instanceIds[i] < instanceIds[j] })
+
+		list = append(list, map[string]interface{}{
+			"id":            strconv.Itoa(int(privateNetwork.PrivateNetworkId)),
+			"name":          privateNetwork.Name,
+			"description":   privateNetwork.Description,
+			"region":        privateNetwork.Region,
+			"data_center":   privateNetwork.DataCenter,
+			"cidr":          privateNetwork.Cidr,
+			"available_ips": privateNetwork.AvailableIps,
+			"created_date":  privateNetwork.CreatedDate.Format(time.RFC850),
+			"instance_ids":  instanceIds,
+		})
+	}
+
+	return list
+}
+
+// groupPrivateNetworksByRegion partitions the Private Networks by region. The
+// groups are sorted by region and keep the order of the listed networks.
+func groupPrivateNetworksByRegion(privateNetworks []apiClient.PrivateNetworkResponse) []map[string]interface{} {
+	idsByRegion := map[string][]string{}
+	regionNames := []string{}
+
+	for _, privateNetwork := range privateNetworks {
+		if _, ok := idsByRegion[privateNetwork.Region]; !ok {
+			regionNames = append(regionNames, privateNetwork.Region)
+		}
+		idsByRegion[privateNetwork.Region] = append(
+			idsByRegion[privateNetwork.Region],
+			strconv.Itoa(int(privateNetwork.PrivateNetworkId)),
+		)
+	}
+	sort.Strings(regionNames)
+
+	regions := []map[string]interface{}{}
+	for _, region := range regionNames {
+		regions = append(regions, map[string]interface{}{
+			"region":              region,
+			"private_network_ids": idsByRegion[region],
+		})
+	}
+
+	return regions
+}
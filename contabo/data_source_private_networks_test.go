@@ -0,0 +1,139 @@
+package contabo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func testPrivateNetworkInRegionJson(privateNetworkId int, region string) string {
+	return fmt.Sprintf(
+		`{"tenantId":"DE","customerId":"54321","privateNetworkId":%d,"dataCenter":"","region":"%s","regionName":"","name":"network%[1]d","description":"","cidr":"10.0.0.0/22","availableIps":1019,"createdDate":"2022-01-01T00:00:00Z","instances":[]}`,
+		privateNetworkId, region)
+}
+
+func TestPrivateNetworksGroupedByRegion(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/private-networks" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		switch r.URL.Query().Get("page") {
+		case "1":
+			writeJson(w, http.StatusOK, fmt.Sprintf(
+				`{"_pagination":{"size":2,"totalElements":3,"totalPages":2,"page":1},"data":[%s,%s],"_links":{"self":"/"}}`,
+				testPrivateNetworkInRegionJson(1, "EU"), testPrivateNetworkInRegionJson(2, "US-central")))
+		case "2":
+			writeJson(w, http.StatusOK, fmt.Sprintf(
+				`{"_pagination":{"size":2,"totalElements":3,"totalPages":2,"page":2},"data":[%s],"_links":{"self":"/"}}`,
+				testPrivateNetworkInRegionJson(3, "EU")))
+		default:
+			t.Fatalf("unexpected page %s", r.URL.Query().Get("page"))
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourcePrivateNetworks().Schema, map[string]interface{}{
+		"group_by_region": true,
+	})
+
+	if diags := dataSourcePrivateNetworksRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	// partition the flat list by region and compare it with the groups
+	partitioned := map[string][]string{}
+	for _, privateNetwork := range d.Get("private_networks").([]interface{}) {
+		privateNetwork := privateNetwork.(map[string]interface{})
+		region := privateNetwork["region"].(string)
+		partitioned[region] = append(partitioned[region], privateNetwork["id"].(string))
+	}
+	if len(d.Get("private_networks").([]interface{})) != 3 {
+		t.Fatalf("expected all pages to be listed, got %v", d.Get("private_networks"))
+	}
+
+	regions := d.Get("regions").([]interface{})
+	if len(regions) != len(partitioned) {
+		t.Fatalf("expected %d regions, got %d", len(partitioned), len(regions))
+	}
+	for _, group := range regions {
+		group := group.(map[string]interface{})
+		ids := group["private_network_ids"].([]interface{})
+		expected := partitioned[group["region"].(string)]
+		if len(ids) != len(expected) {
+			t.Fatalf("expected %v in region %s, got %v", expected, group["region"], ids)
+		}
+		for i := range ids {
+			if ids[i].(string) != expected[i] {
+				t.Fatalf("expected %v in region %s, got %v", expected, group["region"], ids)
+			}
+		}
+	}
+}
+
+func TestPrivateNetworksWithoutPaginationMetadata(t *testing.T) {
+	requests := 0
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/private-networks" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		requests++
+		if requests > 1 {
+			t.Fatalf("expected a single request, the first page is not full, got page %s", r.URL.Query().Get("page"))
+		}
+		// a proxy stripped the _pagination envelope
+		writeJson(w, http.StatusOK, fmt.Sprintf(`{"data":[%s,%s],"_links":{"self":"/"}}`,
+			testPrivateNetworkInRegionJson(1, "EU"), testPrivateNetworkInRegionJson(2, "EU")))
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourcePrivateNetworks().Schema, map[string]interface{}{})
+
+	if diags := dataSourcePrivateNetworksRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if len(d.Get("private_networks").([]interface{})) != 2 {
+		t.Fatalf("expected both Private Networks to be listed, got %v", d.Get("private_networks"))
+	}
+}
+
+func TestPrivateNetworksFilteredByAvailableIps(t *testing.T) {
+	withAvailableIps := func(privateNetworkId int, availableIps int) string {
+		return strings.Replace(
+			testPrivateNetworkInRegionJson(privateNetworkId, "EU"),
+			`"availableIps":1019`,
+			fmt.Sprintf(`"availableIps":%d`, availableIps),
+			1)
+	}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/private-networks" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		writeJson(w, http.StatusOK, listBody(
+			withAvailableIps(1, 3)+","+withAvailableIps(2, 50)+","+withAvailableIps(3, 1019), 3))
+	}))
+
+	for _, test := range []struct {
+		config   map[string]interface{}
+		expected []string
+	}{
+		{map[string]interface{}{}, []string{"1", "2", "3"}},
+		{map[string]interface{}{"max_available_ips": 50}, []string{"1", "2"}},
+		{map[string]interface{}{"min_available_ips": 10}, []string{"2", "3"}},
+		{map[string]interface{}{"min_available_ips": 10, "max_available_ips": 100}, []string{"2"}},
+	} {
+		d := schema.TestResourceDataRaw(t, dataSourcePrivateNetworks().Schema, test.config)
+		if diags := dataSourcePrivateNetworksRead(context.Background(), d, meta); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+
+		ids := []string{}
+		for _, privateNetwork := range d.Get("private_networks").([]interface{}) {
+			ids = append(ids, privateNetwork.(map[string]interface{})["id"].(string))
+		}
+		if strings.Join(ids, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("%v: expected %v, got %v", test.config, test.expected, ids)
+		}
+	}
+}
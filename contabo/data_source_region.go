@@ -0,0 +1,110 @@
+package contabo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// region describes a region of Contabo. The API only knows the slugs, the
+// data centers of a region are recognized by the prefix of their names.
+type region struct {
+	slug             string
+	name             string
+	dataCenterPrefix string
+	aliases          []string
+}
+
+var regions = []region{
+	{"EU", "European Union", "European Union", []string{"europe", "germany", "de"}},
+	{"US-central", "United States (Central)", "United States Central", []string{"usc", "us central"}},
+	{"US-east", "United States (East)", "United States East", []string{"use", "us east"}},
+	{"US-west", "United States (West)", "United States West", []string{"usw", "us west"}},
+	{"SIN", "Asia (Singapore)", "Asia (Singapore)", []string{"singapore", "asia"}},
+}
+
+// findRegion resolves the slug, name or an alias of a region, ignoring the
+// case. It returns nil for unknown regions.
+func findRegion(nameOrAlias string) *region {
+	nameOrAlias = strings.ToLower(strings.TrimSpace(nameOrAlias))
+	for i, region := range regions {
+		if strings.ToLower(region.slug) == nameOrAlias || strings.ToLower(region.name) == nameOrAlias {
+			return &regions[i]
+		}
+		for _, alias := range region.aliases {
+			if alias == nameOrAlias {
+				return &regions[i]
+			}
+		}
+	}
+	return nil
+}
+
+func dataSourceRegion() *schema.Resource {
+	return &schema.Resource{
+		Description: "Resolves the name or an alias of a Contabo region, e.g. `EU` or `singapore`, to the canonical region slug used by the other resources and lists its data centers.",
+		ReadContext: dataSourceRegionRead,
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The slug, name or an alias of the region, e.g. `EU`, `European Union` or `europe`. The case is ignored.",
+			},
+			"slug": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The canonical slug of the region, e.g. `EU`. Use it as `region` of instances, object storages and private networks.",
+			},
+			"region_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The human readable name of the region.",
+			},
+			"data_centers": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "The names of the data centers in the region, e.g. `European Union 1`.",
+			},
+		},
+	}
+}
+
+func dataSourceRegionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	client := m.(*providerMeta).client
+
+	region := findRegion(d.Get("name").(string))
+	if region == nil {
+		return diag.FromErr(fmt.Errorf("unknown region %q", d.Get("name").(string)))
+	}
+
+	dataCenters, err := listDataCenters(ctx, client)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	dataCenterNames := []string{}
+	for _, dataCenter := range dataCenters {
+		if strings.HasPrefix(dataCenter.Name, region.dataCenterPrefix) {
+			dataCenterNames = append(dataCenterNames, dataCenter.Name)
+		}
+	}
+
+	if err := d.Set("slug", region.slug); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("region_name", region.name); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("data_centers", dataCenterNames); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(region.slug)
+
+	return diags
+}
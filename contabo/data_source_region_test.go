@@ -0,0 +1,46 @@
+package contabo
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestRegionResolvesAlias(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+	}))
+
+	for _, name := range []string{"EU", "eu", "europe", "European Union"} {
+		d := schema.TestResourceDataRaw(t, dataSourceRegion().Schema, map[string]interface{}{
+			"name": name,
+		})
+
+		if diags := dataSourceRegionRead(context.Background(), d, meta); diags.HasError() {
+			t.Fatalf("%s: unexpected error: %v", name, diags)
+		}
+		if d.Get("slug").(string) != "EU" || d.Get("region_name").(string) != "European Union" {
+			t.Fatalf("%s: expected the region EU, got %q (%q)", name, d.Get("slug"), d.Get("region_name"))
+		}
+		dataCenters := d.Get("data_centers").([]interface{})
+		if len(dataCenters) != 1 || dataCenters[0].(string) != "European Union 1" {
+			t.Fatalf("%s: expected the data center European Union 1, got %v", name, dataCenters)
+		}
+	}
+}
+
+func TestRegionUnknownAlias(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceRegion().Schema, map[string]interface{}{
+		"name": "atlantis",
+	})
+
+	if diags := dataSourceRegionRead(context.Background(), d, meta); !diags.HasError() {
+		t.Fatal("expected an error for an unknown region")
+	}
+}
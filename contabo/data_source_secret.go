@@ -4,7 +4,6 @@ import (
 	"context"
 	"strconv"
 
-	apiClient "contabo.com/openapi"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	uuid "github.com/satori/go.uuid"
@@ -53,7 +52,7 @@ func dataSourceSecret() *schema.Resource {
 
 func dataSourceSecretRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*apiClient.APIClient)
+	client := m.(*providerMeta).client
 
 	var secretId int64
 	var err error
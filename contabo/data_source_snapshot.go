@@ -4,7 +4,6 @@ import (
 	"context"
 	"strconv"
 
-	apiClient "contabo.com/openapi"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	uuid "github.com/satori/go.uuid"
@@ -64,7 +63,7 @@ func dataSourceSnapshotRead(
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*apiClient.APIClient)
+	client := m.(*providerMeta).client
 
 	var snapshotId string
 	var err error
@@ -0,0 +1,144 @@
+package contabo
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"contabo.com/openapi"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	uuid "github.com/satori/go.uuid"
+)
+
+func dataSourceTags() *schema.Resource {
+	return &schema.Resource{
+		Description: "Lists all [tags](https://api.contabo.com/#tag/Tags), optionally with the number of resources they are assigned to, e.g. to audit the tagging of the resources.",
+		ReadContext: dataSourceTagsRead,
+		Schema: map[string]*schema.Schema{
+			"include_assignments": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, `assignment_counts` of every tag is filled. This needs an additional API call per tag.",
+			},
+			"tags": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The listed tags.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"tag_id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The identifier of the tag.",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The name of the tag.",
+						},
+						"color": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The color of the tag, e.g. `#0A78C3`.",
+						},
+						"assignment_counts": {
+							Type:        schema.TypeMap,
+							Elem:        &schema.Schema{Type: schema.TypeInt},
+							Computed:    true,
+							Description: "The number of resources the tag is assigned to by resource type, e.g. `instance`. Only set if `include_assignments` is set.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceTagsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+	client := m.(*providerMeta).client
+
+	tags, err := listTags(ctx, client)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	list := []map[string]interface{}{}
+	for _, tag := range tags {
+		assignmentCounts := map[string]int{}
+		if d.Get("include_assignments").(bool) {
+			assignmentCounts, err = countTagAssignments(ctx, client, tag.TagId)
+			if err != nil {
+				return diag.FromErr(err)
+			}
+		}
+
+		list = append(list, map[string]interface{}{
+			"tag_id":            tag.TagId,
+			"name":              tag.Name,
+			"color":             tag.Color,
+			"assignment_counts": assignmentCounts,
+		})
+	}
+
+	if err := d.Set("tags", list); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
+
+	return diags
+}
+
+// listTags fetches all pages of tags.
+func listTags(ctx context.Context, client *openapi.APIClient) ([]openapi.TagResponse, error) {
+	tags := []openapi.TagResponse{}
+
+	err := paginate(tagPageSize, func(page int64) (int, int64, error) {
+		res, httpResp, err := client.TagsApi.
+			RetrieveTagList(ctx).
+			XRequestId(uuid.NewV4().String()).
+			Page(page).
+			Size(tagPageSize).
+			Execute()
+		if err != nil {
+			return 0, 0, NewApiError(httpResp, err)
+		}
+
+		tags = append(tags, res.Data...)
+		return len(res.Data), int64(res.Pagination.TotalPages), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// countTagAssignments fetches all pages of assignments of the tag and counts
+// them by resource type.
+func countTagAssignments(ctx context.Context, client *openapi.APIClient, tagId int64) (map[string]int, error) {
+	counts := map[string]int{}
+
+	err := paginate(tagPageSize, func(page int64) (int, int64, error) {
+		res, httpResp, err := client.TagAssignmentsApi.
+			RetrieveAssignmentList(ctx, tagId).
+			XRequestId(uuid.NewV4().String()).
+			Page(page).
+			Size(tagPageSize).
+			Execute()
+		if err != nil {
+			return 0, 0, NewApiError(httpResp, err)
+		}
+
+		for _, assignment := range res.Data {
+			counts[assignment.ResourceType]++
+		}
+		return len(res.Data), int64(res.Pagination.TotalPages), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,96 @@
+package contabo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func testTagJson(tagId int, name string) string {
+	return fmt.Sprintf(
+		`{"tenantId":"DE","customerId":"54321","tagId":%d,"name":"%s","color":"#0A78C3"}`,
+		tagId, name)
+}
+
+func testAssignmentJson(tagId int, resourceType string, resourceId string) string {
+	return fmt.Sprintf(
+		`{"tenantId":"DE","customerId":"54321","tagId":%d,"tagName":"","resourceType":"%s","resourceId":"%s","resourceName":""}`,
+		tagId, resourceType, resourceId)
+}
+
+func TestTagsWithAssignmentCounts(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		switch {
+		case r.URL.Path == "/v1/tags" && page == "1":
+			writeJson(w, http.StatusOK, fmt.Sprintf(
+				`{"_pagination":{"size":2,"totalElements":3,"totalPages":2,"page":1},"data":[%s,%s],"_links":{"self":"/"}}`,
+				testTagJson(1, "production"), testTagJson(2, "staging")))
+		case r.URL.Path == "/v1/tags" && page == "2":
+			writeJson(w, http.StatusOK, fmt.Sprintf(
+				`{"_pagination":{"size":2,"totalElements":3,"totalPages":2,"page":2},"data":[%s],"_links":{"self":"/"}}`,
+				testTagJson(3, "unused")))
+		case r.URL.Path == "/v1/tags/1/assignments" && page == "1":
+			writeJson(w, http.StatusOK, fmt.Sprintf(
+				`{"_pagination":{"size":2,"totalElements":3,"totalPages":2,"page":1},"data":[%s,%s],"_links":{"self":"/"}}`,
+				testAssignmentJson(1, instanceTagResourceType, "12345"),
+				testAssignmentJson(1, instanceTagResourceType, "12346")))
+		case r.URL.Path == "/v1/tags/1/assignments" && page == "2":
+			writeJson(w, http.StatusOK, fmt.Sprintf(
+				`{"_pagination":{"size":2,"totalElements":3,"totalPages":2,"page":2},"data":[%s],"_links":{"self":"/"}}`,
+				testAssignmentJson(1, privateNetworkTagResourceType, "7")))
+		case r.URL.Path == "/v1/tags/2/assignments":
+			writeJson(w, http.StatusOK, listBody(testAssignmentJson(2, instanceTagResourceType, "12345"), 1))
+		case r.URL.Path == "/v1/tags/3/assignments":
+			writeJson(w, http.StatusOK, listBody("", 0))
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceTags().Schema, map[string]interface{}{
+		"include_assignments": true,
+	})
+
+	if diags := dataSourceTagsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	expected := map[string]map[string]interface{}{
+		"production": {instanceTagResourceType: 2, privateNetworkTagResourceType: 1},
+		"staging":    {instanceTagResourceType: 1},
+		"unused":     {},
+	}
+	tags := d.Get("tags").([]interface{})
+	if len(tags) != len(expected) {
+		t.Fatalf("expected all pages of tags to be listed, got %v", tags)
+	}
+	for _, tag := range tags {
+		tag := tag.(map[string]interface{})
+		counts := tag["assignment_counts"].(map[string]interface{})
+		if fmt.Sprint(counts) != fmt.Sprint(expected[tag["name"].(string)]) {
+			t.Fatalf("expected the assignment counts %v for tag %v, got %v", expected[tag["name"].(string)], tag["name"], counts)
+		}
+	}
+}
+
+func TestTagsWithoutAssignments(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/tags" {
+			t.Fatalf("assignments must only be listed if included, got %s %s", r.Method, r.URL)
+		}
+		writeJson(w, http.StatusOK, listBody(testTagJson(1, "production"), 1))
+	}))
+
+	d := schema.TestResourceDataRaw(t, dataSourceTags().Schema, map[string]interface{}{})
+
+	if diags := dataSourceTagsRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if tagId := d.Get("tags.0.tag_id").(int); tagId != 1 {
+		t.Fatalf("expected tag 1, got %d", tagId)
+	}
+}
@@ -0,0 +1,123 @@
+package contabo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// redactedValue replaces the values of sensitive fields in debug output.
+const redactedValue = "REDACTED"
+
+// sensitiveResponseFields are the fields of API responses which must not end
+// up in the state in plain text.
+var sensitiveResponseFields = map[string]bool{
+	"accessKey":    true,
+	"password":     true,
+	"rootPassword": true,
+	"secret":       true,
+	"secretKey":    true,
+	"userData":     true,
+	"value":        true,
+}
+
+func debugLastResponseSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "The raw JSON of the last API response for the resource with sensitive fields redacted, e.g. to report a bug. Only set if `debug` is enabled in the provider.",
+	}
+}
+
+func debugLastReadDurationSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeInt,
+		Computed:    true,
+		Description: "The duration of the last successful read of the resource from the API in milliseconds, e.g. to spot slow API responses. Only set if `debug` is enabled in the provider.",
+	}
+}
+
+// AddDebugLastResponseToData stores the redacted body of the response in
+// _debug_last_response if debugging is enabled in the provider.
+func AddDebugLastResponseToData(
+	m interface{},
+	httpResp *http.Response,
+	d *schema.ResourceData,
+	diags diag.Diagnostics,
+) diag.Diagnostics {
+	if !m.(*providerMeta).debug || httpResp == nil || httpResp.Body == nil {
+		return diags
+	}
+
+	body, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	// others may still read the body
+	httpResp.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+
+	redacted, err := redactResponse(body)
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	if err := d.Set("_debug_last_response", redacted); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
+
+// AddDebugReadDurationToData stores the time since the read started in
+// last_read_duration_ms if debugging is enabled in the provider.
+func AddDebugReadDurationToData(
+	m interface{},
+	start time.Time,
+	d *schema.ResourceData,
+	diags diag.Diagnostics,
+) diag.Diagnostics {
+	if !m.(*providerMeta).debug {
+		return diags
+	}
+
+	if err := d.Set("last_read_duration_ms", time.Since(start).Milliseconds()); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
+
+// redactResponse replaces the values of all sensitive fields of the JSON
+// response, at any depth.
+func redactResponse(body []byte) (string, error) {
+	var response interface{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", err
+	}
+
+	redacted, err := json.Marshal(redactValue(response))
+	if err != nil {
+		return "", err
+	}
+	return string(redacted), nil
+}
+
+func redactValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if sensitiveResponseFields[key] && field != nil && field != "" {
+				value[key] = redactedValue
+			} else {
+				value[key] = redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactValue(item)
+		}
+	}
+	return value
+}
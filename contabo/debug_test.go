@@ -0,0 +1,74 @@
+package contabo
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestSecretReadStoresRedactedDebugResponse(t *testing.T) {
+	for _, debug := range []bool{false, true} {
+		meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || r.URL.Path != "/v1/secrets/42" {
+				t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+			}
+			writeJson(w, http.StatusOK, `{"data":[{"tenantId":"DE","customerId":"54321","secretId":42,"name":"deploy","type":"password","value":"hunter2",`+
+				`"createdAt":"2022-01-01T00:00:00Z","updatedAt":"2022-01-01T00:00:00Z"}],"_links":{"self":"/"}}`)
+		}))
+		meta.debug = debug
+
+		d := schema.TestResourceDataRaw(t, resourceSecret().Schema, map[string]interface{}{})
+		d.SetId("42")
+
+		if diags := resourceSecretRead(context.Background(), d, meta); diags.HasError() {
+			t.Fatalf("unexpected error with debug=%t: %v", debug, diags)
+		}
+
+		lastResponse := d.Get("_debug_last_response").(string)
+		if !debug {
+			if lastResponse != "" {
+				t.Fatalf("expected no debug response with debug disabled, got %s", lastResponse)
+			}
+			continue
+		}
+		if !strings.Contains(lastResponse, `"name":"deploy"`) {
+			t.Fatalf("expected the raw response to be stored, got %s", lastResponse)
+		}
+		if strings.Contains(lastResponse, "hunter2") || !strings.Contains(lastResponse, `"value":"REDACTED"`) {
+			t.Fatalf("expected the secret value to be redacted, got %s", lastResponse)
+		}
+	}
+}
+
+func TestSecretReadStoresReadDuration(t *testing.T) {
+	for _, debug := range []bool{false, true} {
+		meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(20 * time.Millisecond)
+			writeJson(w, http.StatusOK, `{"data":[{"tenantId":"DE","customerId":"54321","secretId":42,"name":"deploy","type":"password","value":"hunter2",`+
+				`"createdAt":"2022-01-01T00:00:00Z","updatedAt":"2022-01-01T00:00:00Z"}],"_links":{"self":"/"}}`)
+		}))
+		meta.debug = debug
+
+		d := schema.TestResourceDataRaw(t, resourceSecret().Schema, map[string]interface{}{})
+		d.SetId("42")
+
+		if diags := resourceSecretRead(context.Background(), d, meta); diags.HasError() {
+			t.Fatalf("unexpected error with debug=%t: %v", debug, diags)
+		}
+
+		duration, ok := d.GetOk("last_read_duration_ms")
+		if !debug {
+			if ok {
+				t.Fatalf("expected no read duration with debug disabled, got %v", duration)
+			}
+			continue
+		}
+		if !ok || duration.(int) < 20 {
+			t.Fatalf("expected a read duration of at least 20ms, got %v", duration)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package contabo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"contabo.com/openapi"
+)
+
+// testDataCentersJson are the data centers known to the fake API.
+const testDataCentersJson = `
+	{"tenantId":"DE","customerId":"54321","name":"European Union 1","slug":"EU1",
+	"capabilities":["VPS","VDS","Object-Storage"],"s3Url":"https://eu2.contabostorage.com"},
+	{"tenantId":"DE","customerId":"54321","name":"United States Central 1","slug":"US-central-1",
+	"capabilities":["VPS","VDS","Object-Storage"],"s3Url":"https://usc1.contabostorage.com"}`
+
+// newFakeClient returns the provider meta with an API client talking to an
+// in-process http server serving the given handler instead of the Contabo API.
+// The data centers are served by the fake itself.
+func newFakeClient(t *testing.T, handler http.Handler) *providerMeta {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/v1/data-centers" {
+			writeJson(w, http.StatusOK, listBody(testDataCentersJson, 2))
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	configuration := openapi.NewConfiguration()
+	configuration.HTTPClient = server.Client()
+	configuration.Servers = []openapi.ServerConfiguration{{URL: server.URL}}
+
+	return &providerMeta{
+		client:     openapi.NewAPIClient(configuration),
+		maxRetries: defaultMaxRetries,
+	}
+}
+
+func writeJson(w http.ResponseWriter, statusCode int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	fmt.Fprint(w, body)
+}
+
+// listBody wraps the given data objects into the paginated envelope of the API.
+func listBody(data string, totalElements int) string {
+	return fmt.Sprintf(
+		`{"_pagination":{"size":100,"totalElements":%d,"totalPages":1,"page":1},"data":[%s],"_links":{"self":"/"}}`,
+		totalElements, data)
+}
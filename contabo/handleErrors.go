@@ -1,57 +1,227 @@
 package contabo
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// ApiError is the typed representation of a failed API call. It allows
+// internal logic like retry predicates to switch on the status code instead
+// of matching error strings.
 type ApiError struct {
-	StatusCode uint16 `json:"statusCode`
-	Message    string `json:"message"`
+	StatusCode  int             `json:"statusCode"`
+	Message     string          `json:"message"`
+	FieldErrors []ApiFieldError `json:"errors,omitempty"`
+	RequestID   string          `json:"-"`
 }
 
-func HandleResponseErrors(
+// ApiFieldError is the validation error of a single field of the payload,
+// which the API returns along with the status code 422.
+type ApiFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *ApiError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("API error, status code: %d, request id: %s, details: %s", e.StatusCode, e.RequestID, e.Message)
+	}
+	return fmt.Sprintf("API error, status code: %d, details: %s", e.StatusCode, e.Message)
+}
+
+func (e *ApiError) IsNotFound() bool {
+	return e != nil && e.StatusCode == http.StatusNotFound
+}
+
+func (e *ApiError) IsConflict() bool {
+	return e != nil && e.StatusCode == http.StatusConflict
+}
+
+// IsTransient reports failures which might succeed when retried: calls
+// without response, rate limited calls and server errors.
+func (e *ApiError) IsTransient() bool {
+	return e != nil && (e.StatusCode == 0 ||
+		e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode >= http.StatusInternalServerError)
+}
+
+// NewApiError builds an ApiError from the http response of a failed call.
+// The response body is restored so that it can be read again afterwards.
+func NewApiError(httpResp *http.Response, err error) *ApiError {
+	apiError := &ApiError{}
+
+	if httpResp == nil {
+		apiError.Message = "Unexpected API error, no http response"
+		if err != nil {
+			apiError.Message = fmt.Sprintf("%s: %s", apiError.Message, err.Error())
+		}
+		return apiError
+	}
+
+	if httpResp.Request != nil {
+		apiError.RequestID = httpResp.Request.Header.Get("x-request-id")
+	}
+
+	var responseBody []byte
+	if httpResp.Body != nil {
+		var readErr error
+		responseBody, readErr = ioutil.ReadAll(httpResp.Body)
+		httpResp.Body.Close()
+		httpResp.Body = ioutil.NopCloser(bytes.NewBuffer(responseBody))
+		if readErr != nil {
+			apiError.StatusCode = httpResp.StatusCode
+			apiError.Message = "Error while reading response error: " + readErr.Error()
+			return apiError
+		}
+	}
+
+	if unmarshalErr := json.Unmarshal(responseBody, apiError); unmarshalErr != nil {
+		apiError.Message = unmarshalErr.Error() + string(responseBody)
+	}
+
+	// the http status code is authoritative, the body might lack it
+	apiError.StatusCode = httpResp.StatusCode
+
+	return apiError
+}
+
+// HandleApiError classifies a failed call and returns the typed error
+// alongside the diagnostics reported to the user.
+func HandleApiError(
 	diags diag.Diagnostics,
 	httpResp *http.Response,
-) diag.Diagnostics {
-	var apiError ApiError
-	var responseBody []byte
-	var err error
+	err error,
+) (*ApiError, diag.Diagnostics) {
+	apiError := NewApiError(httpResp, err)
 
-	if httpResp != nil {
-		responseBody, err = ioutil.ReadAll(httpResp.Body)
-	} else {
-		return append(diags, diag.Diagnostic{
+	if httpResp == nil {
+		return apiError, append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Unexpected API error, no http response",
-			Detail:   "Unexpected API error, no http response",
+			Detail:   apiError.Message,
 		})
 	}
 
-	if err != nil {
-		log.Panic("Error while parsing response error")
+	if apiError.StatusCode == http.StatusUnprocessableEntity && len(apiError.FieldErrors) > 0 {
+		return apiError, append(diags, fieldErrorDiagnostics(apiError)...)
+	}
+
+	return apiError, append(diags, diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  fmt.Sprintf("API error, status code: %d", apiError.StatusCode),
+		Detail:   apiError.Error(),
+	})
+}
+
+// fieldErrorDiagnostics returns a diagnostic per invalid field of a 422
+// response, pointing at the attribute of the field so that Terraform shows it
+// at the offending line of the configuration.
+func fieldErrorDiagnostics(apiError *ApiError) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, fieldError := range apiError.FieldErrors {
+		fieldApiError := *apiError
+		fieldApiError.Message = fmt.Sprintf("%s: %s", fieldError.Field, fieldError.Message)
+
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf("Invalid value of %s", fieldError.Field),
+			Detail:        fieldApiError.Error(),
+			AttributePath: fieldAttributePath(fieldError.Field),
+		})
 	}
+	return diags
+}
 
-	err = json.Unmarshal(responseBody, &apiError)
+// fieldAttributePath maps a field of the API payload, e.g. `displayName` or
+// `sshKeys.1`, to the path of the attribute, e.g. `display_name` or
+// `ssh_keys[1]`. The attributes follow the field names in snake case. The
+// elements of sets have no index, so the path of `instanceIds.1` stops at
+// `instance_ids`. An empty field has no path.
+func fieldAttributePath(field string) cty.Path {
+	var path cty.Path
+	if field == "" {
+		return path
+	}
 
-	var errorMessage string
-	if err != nil {
-		errorMessage = err.Error() + string(responseBody)
-	} else {
-		errorMessage = apiError.Message
+	attribute := ""
+	for _, step := range strings.Split(field, ".") {
+		if index, err := strconv.Atoi(step); err == nil && len(path) > 0 {
+			if isSetAttribute(attribute) {
+				break
+			}
+			path = path.IndexInt(index)
+		} else {
+			attribute = snakeCase(step)
+			path = path.GetAttr(attribute)
+		}
 	}
+	return path
+}
 
-	return append(diags, diag.Diagnostic{
-		Severity: diag.Error,
-		Summary:  fmt.Sprintf("API error, status code: %d", apiError.StatusCode),
-		Detail: fmt.Sprintf(
-			"API error, status code: %d, details: %s", apiError.StatusCode, errorMessage),
+var (
+	setAttributesOnce sync.Once
+	setAttributes     map[string]bool
+)
+
+// isSetAttribute reports whether an attribute of that name is a set in any
+// schema of the provider.
+func isSetAttribute(name string) bool {
+	setAttributesOnce.Do(func() {
+		setAttributes = map[string]bool{}
+		provider := Provider()
+		for _, resource := range provider.ResourcesMap {
+			collectSetAttributes(resource.Schema)
+		}
+		for _, dataSource := range provider.DataSourcesMap {
+			collectSetAttributes(dataSource.Schema)
+		}
 	})
+	return setAttributes[name]
+}
+
+func collectSetAttributes(schemaMap map[string]*schema.Schema) {
+	for name, attribute := range schemaMap {
+		if attribute.Type == schema.TypeSet {
+			setAttributes[name] = true
+		}
+		if elem, ok := attribute.Elem.(*schema.Resource); ok {
+			collectSetAttributes(elem.Schema)
+		}
+	}
+}
+
+// snakeCase converts a camel case field name to snake case, keeping
+// abbreviations together, e.g. `totalPurchasedSpaceTB` becomes
+// `total_purchased_space_tb`.
+func snakeCase(name string) string {
+	var builder strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && !unicode.IsUpper(runes[i-1]) {
+			builder.WriteRune('_')
+		}
+		builder.WriteRune(unicode.ToLower(r))
+	}
+	return builder.String()
+}
+
+func HandleResponseErrors(
+	diags diag.Diagnostics,
+	httpResp *http.Response,
+) diag.Diagnostics {
+	_, diags = HandleApiError(diags, httpResp, nil)
+	return diags
 }
 
 func MultipleDataObjectsError(
@@ -0,0 +1,136 @@
+package contabo
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+)
+
+func testErrorResponse(statusCode int, body string) *http.Response {
+	request, _ := http.NewRequest(http.MethodGet, "https://api.contabo.com/v1/private-networks/1", nil)
+	request.Header.Set("x-request-id", "04e0f898-37b4-48bc-a794-1a57abe6aa31")
+
+	return &http.Response{
+		StatusCode: statusCode,
+		Status:     http.StatusText(statusCode),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    request,
+	}
+}
+
+func TestNewApiErrorClassification(t *testing.T) {
+	cases := []struct {
+		statusCode int
+		body       string
+		notFound   bool
+		conflict   bool
+		message    string
+	}{
+		{http.StatusNotFound, `{"statusCode":404,"message":"Entry PrivateNetwork not found"}`, true, false, "Entry PrivateNetwork not found"},
+		{http.StatusConflict, `{"statusCode":409,"message":"Addon already exists"}`, false, true, "Addon already exists"},
+		{http.StatusInternalServerError, `{"message":"boom"}`, false, false, "boom"},
+	}
+
+	for _, c := range cases {
+		apiError := NewApiError(testErrorResponse(c.statusCode, c.body), errors.New(http.StatusText(c.statusCode)))
+
+		if apiError.StatusCode != c.statusCode {
+			t.Errorf("expected status code %d, got %d", c.statusCode, apiError.StatusCode)
+		}
+		if apiError.IsNotFound() != c.notFound {
+			t.Errorf("status %d: expected IsNotFound to be %v", c.statusCode, c.notFound)
+		}
+		if apiError.IsConflict() != c.conflict {
+			t.Errorf("status %d: expected IsConflict to be %v", c.statusCode, c.conflict)
+		}
+		if apiError.Message != c.message {
+			t.Errorf("expected message %q, got %q", c.message, apiError.Message)
+		}
+		if apiError.RequestID != "04e0f898-37b4-48bc-a794-1a57abe6aa31" {
+			t.Errorf("expected request id to be taken from the request, got %q", apiError.RequestID)
+		}
+	}
+}
+
+func TestNewApiErrorKeepsBodyReadable(t *testing.T) {
+	httpResp := testErrorResponse(http.StatusConflict, `{"message":"Addon already exists"}`)
+
+	NewApiError(httpResp, nil)
+	_, diags := HandleApiError(nil, httpResp, nil)
+
+	if len(diags) != 1 || !strings.Contains(diags[0].Detail, "Addon already exists") {
+		t.Fatalf("expected the message in the diagnostic detail, got %v", diags)
+	}
+}
+
+func TestNewApiErrorWithoutResponse(t *testing.T) {
+	apiError, diags := HandleApiError(nil, nil, errors.New("connection refused"))
+
+	if apiError.StatusCode != 0 || apiError.IsNotFound() || apiError.IsConflict() {
+		t.Fatalf("expected an unclassified error, got %+v", apiError)
+	}
+	if len(diags) != 1 || !strings.Contains(diags[0].Detail, "connection refused") {
+		t.Fatalf("expected the transport error in the diagnostic, got %v", diags)
+	}
+}
+
+func TestNilApiErrorIsNotClassified(t *testing.T) {
+	var apiError *ApiError
+
+	if apiError.IsNotFound() || apiError.IsConflict() {
+		t.Fatal("a nil error must not be classified")
+	}
+}
+
+func TestApiErrorIsTransient(t *testing.T) {
+	for statusCode, transient := range map[int]bool{
+		http.StatusNotFound:            false,
+		http.StatusConflict:            false,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusServiceUnavailable:  true,
+	} {
+		apiError := NewApiError(testErrorResponse(statusCode, `{}`), errors.New(http.StatusText(statusCode)))
+		if apiError.IsTransient() != transient {
+			t.Errorf("expected IsTransient of %d to be %t", statusCode, transient)
+		}
+	}
+
+	if !NewApiError(nil, errors.New("connection reset by peer")).IsTransient() {
+		t.Error("expected a call without response to be transient")
+	}
+}
+
+func TestHandleApiErrorMapsFieldErrorsToAttributes(t *testing.T) {
+	httpResp := testErrorResponse(http.StatusUnprocessableEntity, `{"statusCode":422,"message":"Validation failed","errors":[`+
+		`{"field":"displayName","message":"must be shorter than or equal to 255 characters"},`+
+		`{"field":"instanceIds.1","message":"must be a positive number"},`+
+		`{"field":"sshKeys.0","message":"must be a positive number"}]}`)
+
+	_, diags := HandleApiError(nil, httpResp, nil)
+
+	expected := []cty.Path{
+		cty.GetAttrPath("display_name"),
+		// the elements of sets have no index
+		cty.GetAttrPath("instance_ids"),
+		cty.GetAttrPath("ssh_keys").IndexInt(0),
+	}
+	if len(diags) != len(expected) {
+		t.Fatalf("expected a diagnostic per field, got %v", diags)
+	}
+	for i, path := range expected {
+		if !diags[i].AttributePath.Equals(path) {
+			t.Errorf("expected the path %#v, got %#v", path, diags[i].AttributePath)
+		}
+		if !strings.Contains(diags[i].Detail, "04e0f898-37b4-48bc-a794-1a57abe6aa31") {
+			t.Errorf("expected the request id in the detail, got %q", diags[i].Detail)
+		}
+	}
+	if !strings.Contains(diags[0].Detail, "must be shorter than or equal to 255 characters") {
+		t.Errorf("expected the message of the field in the detail, got %q", diags[0].Detail)
+	}
+}
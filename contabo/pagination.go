@@ -0,0 +1,35 @@
+package contabo
+
+import "errors"
+
+// errStopPaging ends paginate early without an error, e.g. once the searched
+// item has been found.
+var errStopPaging = errors.New("stop paging")
+
+// paginate calls fetch for the pages 1, 2, ... until the last page has been
+// fetched. All list endpoints of the API paginate by page number, fetch
+// collects the items of the page itself and returns their number along with
+// the total number of pages of the response. As proxies may strip the
+// pagination metadata, without a total number of pages a page with less than
+// pageSize items is taken as the last page. Returning errStopPaging from fetch
+// stops the pagination without an error, any other error is returned as is.
+func paginate(
+	pageSize int64,
+	fetch func(page int64) (itemCount int, totalPages int64, err error),
+) error {
+	for page := int64(1); ; page++ {
+		itemCount, totalPages, err := fetch(page)
+		if err == errStopPaging {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if totalPages > 0 && page >= totalPages {
+			return nil
+		}
+		if totalPages == 0 && int64(itemCount) < pageSize {
+			return nil
+		}
+	}
+}
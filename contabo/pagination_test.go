@@ -0,0 +1,71 @@
+package contabo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPaginateFetchesAllPages(t *testing.T) {
+	pages := map[int64][]string{1: {"a", "b"}, 2: {"c", "d"}, 3: {"e"}}
+	items := []string{}
+	fetched := []int64{}
+
+	err := paginate(2, func(page int64) (int, int64, error) {
+		fetched = append(fetched, page)
+		items = append(items, pages[page]...)
+		return len(pages[page]), 3, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(fetched, []int64{1, 2, 3}) {
+		t.Errorf("expected the pages 1 to 3 to be fetched, got %v", fetched)
+	}
+	if !reflect.DeepEqual(items, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("expected the items of all pages, got %v", items)
+	}
+}
+
+func TestPaginateWithoutPaginationMetadata(t *testing.T) {
+	pages := map[int64][]string{1: {"a", "b"}, 2: {"c", "d"}, 3: {"e"}, 4: {"f"}}
+	fetched := []int64{}
+
+	// without metadata the total number of pages is reported as 0
+	err := paginate(2, func(page int64) (int, int64, error) {
+		fetched = append(fetched, page)
+		return len(pages[page]), 0, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(fetched, []int64{1, 2, 3}) {
+		t.Errorf("expected to stop after the first partial page 3, fetched %v", fetched)
+	}
+}
+
+func TestPaginateStopsEarly(t *testing.T) {
+	fetched := 0
+	err := paginate(2, func(page int64) (int, int64, error) {
+		fetched++
+		if page == 2 {
+			return 0, 0, errStopPaging
+		}
+		return 2, 3, nil
+	})
+	if err != nil || fetched != 2 {
+		t.Fatalf("expected the pagination to stop after page 2 without error, got %d pages and %v", fetched, err)
+	}
+
+	failure := errors.New("failure")
+	if err := paginate(2, func(page int64) (int, int64, error) { return 2, 3, failure }); err != failure {
+		t.Fatalf("expected the error of the fetch to be returned, got %v", err)
+	}
+}
+
+func TestPaginateEmptyList(t *testing.T) {
+	fetched := 0
+	if err := paginate(2, func(page int64) (int, int64, error) { fetched++; return 0, 0, nil }); err != nil || fetched != 1 {
+		t.Fatalf("expected a single fetch for an empty list, got %d fetches and %v", fetched, err)
+	}
+}
@@ -2,15 +2,46 @@ package contabo
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"contabo.com/openapi"
 	"contabo.com/terraform-provider-contabo/client"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
+// providerMeta is handed to all resources and data sources as meta.
+type providerMeta struct {
+	client *openapi.APIClient
+	// maxRetries is the number of times a failed call is retried.
+	maxRetries int
+	// debug stores the last API response of the resources in their state.
+	debug bool
+	// defaultDescription is used for created resources without description.
+	defaultDescription string
+	// pollInterval is the time between two checks of a wait.
+	pollInterval time.Duration
+	// pollTimeout is the timeout of waits, unless a resource sets its own.
+	pollTimeout time.Duration
+	// treatConflictAsError fails ordering an add-on which the instance
+	// already has instead of taking the conflict as success.
+	treatConflictAsError bool
+	// enforceUniqueNames fails creating a Private Network whose name is
+	// taken already.
+	enforceUniqueNames bool
+	// retryStatusCodes replace the status codes retried by default, if set.
+	retryStatusCodes []int
+}
+
 func Provider() *schema.Provider {
-	return &schema.Provider{
+	provider := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"api": &schema.Schema{
 				Type:        schema.TypeString,
@@ -48,6 +79,102 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("CNTB_OAUTH2_PASS", nil),
 				Description: "API Password (this is a new password which you'll set or change in the [Customer Control Panel](https://new.contabo.com/account/security) under the menu item account secret.)",
 			},
+			"tls_ca_cert": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CNTB_TLS_CA_CERT", ""),
+				Description: "PEM encoded CA certificate bundle, or the path to it, which is trusted in addition to the system roots. Required behind TLS inspecting proxies.",
+			},
+			"tls_insecure": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CNTB_TLS_INSECURE", false),
+				Description: "Disables the verification of the TLS certificates. Only use it for debugging.",
+			},
+			"proxy_url": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CNTB_PROXY_URL", ""),
+				Description: "Proxy for all API calls, e.g. `http://proxy.example.com:3128` or `socks5://proxy.example.com:1080`. By default the `HTTP_PROXY` and `HTTPS_PROXY` environment variables are honored.",
+			},
+			"max_concurrent_mutations": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CNTB_MAX_CONCURRENT_MUTATIONS", 4),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of modifying API calls, e.g. add-on upgrades and private network assignments, running at the same time across all resources. Keeps large applies from running into rate limits. Set to `0` to disable the limit. Default is `4`.",
+			},
+			"max_retries": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CNTB_MAX_RETRIES", defaultMaxRetries),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of times a failed API call is retried, e.g. adding the private networking add-on to an instance which is still provisioning. These calls are not repeated by `max_read_retries` and `max_mutation_retries` as well. Set to `0` to disable retries. Default is `10`.",
+			},
+			"max_read_retries": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CNTB_MAX_READ_RETRIES", defaultMaxReadRetries),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of times a reading API call is repeated on network errors, rate limits and server errors, e.g. to keep a refresh from failing transiently. Set to `0` to disable these retries. Default is `5`.",
+			},
+			"max_mutation_retries": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CNTB_MAX_MUTATION_RETRIES", defaultMaxMutationRetries),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of times a modifying API call is repeated. As it may have been processed already, it is only repeated if it was rate limited. Set to `0` to disable these retries. Default is `1`.",
+			},
+			"retry_status_codes": &schema.Schema{
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeInt, ValidateFunc: validation.IntBetween(400, 599)},
+				Description: "Status codes of failed API calls which are retried, replacing the defaults `429` and `5xx`, e.g. `[409, 429, 503]` if the API behind a proxy answers with unusual status codes. Calls without response are always retried. Modifying calls are retried by the provider only where they are safe to repeat, see `max_mutation_retries`.",
+			},
+			"poll_interval": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CNTB_POLL_INTERVAL", defaultPollInterval.String()),
+				ValidateFunc: validateDuration,
+				Description:  "Time between two checks while waiting for a resource to change its state, e.g. for a new Object Storage to be ready. Default is `5s`.",
+			},
+			"poll_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CNTB_POLL_TIMEOUT", defaultPollTimeout.String()),
+				ValidateFunc: validateDuration,
+				Description:  "Maximum time to wait for a resource to change its state. A timeout set in the `timeouts` block of a resource overrides it. Default is `30m`.",
+			},
+			"request_log_path": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CNTB_REQUEST_LOG_PATH", ""),
+				Description: "If set, a JSON line with the method, path, status, request id and duration of every API call is appended to this file, e.g. as a persistent audit trail of the changes. Headers and bodies are not logged and sensitive query parameters are redacted.",
+			},
+			"default_description": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CNTB_DEFAULT_DESCRIPTION", ""),
+				Description: "Description of created Private Networks and snapshots which do not set one, e.g. `managed-by-terraform`. Explicit descriptions are kept.",
+			},
+			"treat_conflict_as_error": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CNTB_TREAT_CONFLICT_AS_ERROR", false),
+				Description: "If set, a conflict while ordering the private networking add-on of an instance fails the apply. By default the conflict is taken as the add-on being there already. Set it in strict environments to catch unexpected conflicts. Default is `false`.",
+			},
+			"enforce_unique_names": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CNTB_ENFORCE_UNIQUE_NAMES", false),
+				Description: "If set, creating a Private Network fails if another one has the same name already, so that it can be looked up and imported by its name. The API itself allows duplicate names. Default is `false`.",
+			},
+			"debug": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CNTB_DEBUG", false),
+				Description: "If set, the raw JSON of the last API response, with sensitive fields redacted, is stored in the `_debug_last_response` attribute of the resources, e.g. to report a bug. Default is `false`.",
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
 			"contabo_instance":          resourceInstance(),
@@ -58,15 +185,90 @@ func Provider() *schema.Provider {
 			"contabo_private_network":   resourcePrivateNetwork(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"contabo_instance":          dataSourceInstance(),
-			"contabo_instance_snapshot": dataSourceSnapshot(),
-			"contabo_image":             dataSourceImage(),
-			"contabo_object_storage":    dataSourceObjectStorage(),
-			"contabo_secret":            dataSourceSecret(),
-			"contabo_private_network":   dataSourcePrivateNetwork(),
+			"contabo_account":                    dataSourceAccount(),
+			"contabo_instance":                   dataSourceInstance(),
+			"contabo_instance_snapshot":          dataSourceSnapshot(),
+			"contabo_image":                      dataSourceImage(),
+			"contabo_object_storage":             dataSourceObjectStorage(),
+			"contabo_object_storage_credentials": dataSourceObjectStorageCredentials(),
+			"contabo_secret":                     dataSourceSecret(),
+			"contabo_private_network":            dataSourcePrivateNetwork(),
+			"contabo_private_networks":           dataSourcePrivateNetworks(),
+			"contabo_region":                     dataSourceRegion(),
+			"contabo_tags":                       dataSourceTags(),
 		},
 		ConfigureContextFunc: providerConfigure,
 	}
+
+	for _, resource := range provider.ResourcesMap {
+		requireConfiguredProvider(resource)
+	}
+	for _, dataSource := range provider.DataSourcesMap {
+		requireConfiguredProvider(dataSource)
+	}
+	return provider
+}
+
+// errProviderNotConfigured is returned by the operations of resources and
+// data sources run without a configured API client.
+var errProviderNotConfigured = errors.New("provider not configured: the Contabo API client could not be set up, check the provider configuration")
+
+// isProviderConfigured reports whether the meta handed to an operation holds
+// an API client.
+func isProviderConfigured(m interface{}) bool {
+	meta, ok := m.(*providerMeta)
+	return ok && meta != nil && meta.client != nil
+}
+
+// requireConfiguredProvider wraps the operations of the resource so that they
+// fail with errProviderNotConfigured instead of panicking if the provider
+// could not build its API client.
+func requireConfiguredProvider(resource *schema.Resource) {
+	if resource.CreateContext != nil {
+		resource.CreateContext = schema.CreateContextFunc(requireConfiguredClient(resource.CreateContext))
+	}
+	if resource.CreateWithoutTimeout != nil {
+		resource.CreateWithoutTimeout = schema.CreateContextFunc(requireConfiguredClient(resource.CreateWithoutTimeout))
+	}
+	if resource.ReadContext != nil {
+		resource.ReadContext = schema.ReadContextFunc(requireConfiguredClient(resource.ReadContext))
+	}
+	if resource.ReadWithoutTimeout != nil {
+		resource.ReadWithoutTimeout = schema.ReadContextFunc(requireConfiguredClient(resource.ReadWithoutTimeout))
+	}
+	if resource.UpdateContext != nil {
+		resource.UpdateContext = schema.UpdateContextFunc(requireConfiguredClient(resource.UpdateContext))
+	}
+	if resource.UpdateWithoutTimeout != nil {
+		resource.UpdateWithoutTimeout = schema.UpdateContextFunc(requireConfiguredClient(resource.UpdateWithoutTimeout))
+	}
+	if resource.DeleteContext != nil {
+		resource.DeleteContext = schema.DeleteContextFunc(requireConfiguredClient(resource.DeleteContext))
+	}
+	if resource.DeleteWithoutTimeout != nil {
+		resource.DeleteWithoutTimeout = schema.DeleteContextFunc(requireConfiguredClient(resource.DeleteWithoutTimeout))
+	}
+
+	if resource.Importer != nil && resource.Importer.StateContext != nil {
+		next := resource.Importer.StateContext
+		resource.Importer.StateContext = func(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+			if !isProviderConfigured(m) {
+				return nil, errProviderNotConfigured
+			}
+			return next(ctx, d, m)
+		}
+	}
+}
+
+func requireConfiguredClient(
+	next func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics,
+) func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics {
+	return func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+		if !isProviderConfigured(m) {
+			return diag.FromErr(errProviderNotConfigured)
+		}
+		return next(ctx, d, m)
+	}
 }
 
 func providerConfigure(
@@ -75,18 +277,46 @@ func providerConfigure(
 ) (interface{}, diag.Diagnostics) {
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
+	if diags := validateCredentials(d); diags.HasError() {
+		return nil, diags
+	}
+
 	apiUrl := d.Get("api").(string)
 	authUrl := d.Get("oauth2_token_url").(string)
 	clientId := d.Get("oauth2_client_id").(string)
 	clientSecret := d.Get("oauth2_client_secret").(string)
 	username := d.Get("oauth2_user").(string)
 	password := d.Get("oauth2_pass").(string)
+	maxConcurrentMutations := d.Get("max_concurrent_mutations").(int)
+	tlsCACert := d.Get("tls_ca_cert").(string)
+	tlsInsecure := d.Get("tls_insecure").(bool)
+	proxyUrl := d.Get("proxy_url").(string)
+	maxRetries := d.Get("max_retries").(int)
+	maxReadRetries := d.Get("max_read_retries").(int)
+	maxMutationRetries := d.Get("max_mutation_retries").(int)
+	requestLogPath := d.Get("request_log_path").(string)
+	retryStatusCodes := []int{}
+	for _, statusCode := range d.Get("retry_status_codes").([]interface{}) {
+		retryStatusCodes = append(retryStatusCodes, statusCode.(int))
+	}
+	debug := d.Get("debug").(bool)
+	treatConflictAsError := d.Get("treat_conflict_as_error").(bool)
+	enforceUniqueNames := d.Get("enforce_unique_names").(bool)
+	// both are validated durations
+	pollInterval, _ := time.ParseDuration(d.Get("poll_interval").(string))
+	pollTimeout, _ := time.ParseDuration(d.Get("poll_timeout").(string))
+	defaultDescription := d.Get("default_description").(string)
 
 	parsedTokenUrl, err := url.ParseRequestURI(authUrl)
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
 
+	noop, diags := noopMode(diags)
+	if diags.HasError() {
+		return nil, diags
+	}
+
 	newClient, err := client.NewClient(
 		apiUrl,
 		parsedTokenUrl.String(),
@@ -94,10 +324,105 @@ func providerConfigure(
 		&clientSecret,
 		username,
 		&password,
+		client.Options{
+			MaxConcurrentMutations: maxConcurrentMutations,
+			TLSCACert:              tlsCACert,
+			TLSInsecure:            tlsInsecure,
+			ProxyURL:               proxyUrl,
+			ReadRetries:            client.RetryPolicy{MaxRetries: maxReadRetries, Delay: retryDelay, StatusCodes: retryStatusCodes},
+			MutationRetries:        client.RetryPolicy{MaxRetries: maxMutationRetries, Delay: retryDelay},
+			RequestLogPath:         requestLogPath,
+			Noop:                   noop,
+		},
 	)
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
 
-	return newClient, diags
+	return &providerMeta{
+		client:               newClient,
+		maxRetries:           maxRetries,
+		debug:                debug,
+		defaultDescription:   defaultDescription,
+		pollInterval:         pollInterval,
+		pollTimeout:          pollTimeout,
+		treatConflictAsError: treatConflictAsError,
+		enforceUniqueNames:   enforceUniqueNames,
+		retryStatusCodes:     retryStatusCodes,
+	}, diags
+}
+
+// noopMode reports whether the no-op mode is enabled by the environment, in
+// which nothing is changed in the account. It is deliberately no provider
+// argument, so that it can not end up in a committed configuration.
+func noopMode(diags diag.Diagnostics) (bool, diag.Diagnostics) {
+	value, ok := os.LookupEnv(client.NoopEnvVar)
+	if !ok || value == "" {
+		return false, diags
+	}
+
+	noop, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("Invalid %s", client.NoopEnvVar),
+			Detail:   fmt.Sprintf("%s must be true or false, got %q.", client.NoopEnvVar, value),
+		})
+	}
+	if !noop {
+		return false, diags
+	}
+
+	return true, append(diags, diag.Diagnostic{
+		Severity: diag.Warning,
+		Summary:  "NO-OP MODE: nothing is changed in the Contabo account",
+		Detail: fmt.Sprintf("%s is set, so creates, updates and deletes are only logged and answered with a synthesized success. "+
+			"The state does not match the account afterwards, use a throwaway state and unset %s for real applies.",
+			client.NoopEnvVar, client.NoopEnvVar),
+	})
+}
+
+// descriptionOrDefault returns the configured description of the resource or,
+// if it is not set, the default_description of the provider.
+func descriptionOrDefault(d *schema.ResourceData, m interface{}) string {
+	if description := d.Get("description").(string); description != "" {
+		return description
+	}
+	return m.(*providerMeta).defaultDescription
+}
+
+// credentials are the provider arguments which are all needed to get a token,
+// along with the environment variables they default to.
+var credentials = []struct {
+	argument string
+	envVar   string
+}{
+	{"oauth2_client_id", "CNTB_OAUTH2_CLIENT_ID"},
+	{"oauth2_client_secret", "CNTB_OAUTH2_CLIENT_SECRET"},
+	{"oauth2_user", "CNTB_OAUTH2_USER"},
+	{"oauth2_pass", "CNTB_OAUTH2_PASS"},
+}
+
+// validateCredentials fails with the list of missing credentials unless all
+// of them are set. The token is requested with the client credentials and the
+// API user together, any subset only results in a vague error of the token
+// endpoint.
+func validateCredentials(d *schema.ResourceData) diag.Diagnostics {
+	missing := []string{}
+	for _, credential := range credentials {
+		if d.Get(credential.argument).(string) == "" {
+			missing = append(missing, fmt.Sprintf("`%s` (or %s)", credential.argument, credential.envVar))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return diag.Diagnostics{{
+		Severity: diag.Error,
+		Summary:  "Incomplete credentials",
+		Detail: fmt.Sprintf(
+			"The oauth2 client id and secret as well as the API user and password are required, missing: %s.",
+			strings.Join(missing, ", ")),
+	}}
 }
@@ -1,9 +1,13 @@
 package contabo
 
 import (
+	"context"
 	"os"
+	"strings"
 	"testing"
 
+	"contabo.com/terraform-provider-contabo/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
@@ -47,3 +51,112 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal("CNTB_OAUTH2_PASS must be set")
 	}
 }
+
+func TestProviderConfigureRequiresCompleteCredentials(t *testing.T) {
+	for _, credential := range credentials {
+		t.Setenv(credential.envVar, "")
+	}
+
+	// every subset of the credentials but the complete one
+	for set := 0; set < 1<<len(credentials)-1; set++ {
+		config := map[string]interface{}{}
+		missing := []string{}
+		for i, credential := range credentials {
+			if set&(1<<i) != 0 {
+				config[credential.argument] = "value"
+			} else {
+				missing = append(missing, credential.argument)
+			}
+		}
+
+		d := schema.TestResourceDataRaw(t, Provider().Schema, config)
+		_, diags := providerConfigure(context.Background(), d)
+
+		if !diags.HasError() {
+			t.Fatalf("expected an error without %v", missing)
+		}
+		for _, credential := range credentials {
+			expected := false
+			for _, argument := range missing {
+				expected = expected || argument == credential.argument
+			}
+			if strings.Contains(diags[0].Detail, "`"+credential.argument+"`") != expected {
+				t.Errorf("expected only %v to be listed as missing, got %q", missing, diags[0].Detail)
+			}
+		}
+	}
+}
+
+func TestNoopModeIsGatedByEnvironment(t *testing.T) {
+	for value, expected := range map[string]bool{"": false, "false": false, "1": true, "true": true} {
+		t.Setenv(client.NoopEnvVar, value)
+
+		noop, diags := noopMode(nil)
+		if noop != expected || diags.HasError() {
+			t.Fatalf("expected %s=%q to enable the no-op mode: %t, got %t %v", client.NoopEnvVar, value, expected, noop, diags)
+		}
+		if warned := len(diags) == 1 && diags[0].Severity == diag.Warning; warned != expected {
+			t.Fatalf("expected a warning only in no-op mode, got %v for %q", diags, value)
+		}
+	}
+
+	t.Setenv(client.NoopEnvVar, "maybe")
+	if _, diags := noopMode(nil); !diags.HasError() {
+		t.Fatal("expected an invalid value to be rejected")
+	}
+}
+
+func TestOperationsWithoutClientFailGracefully(t *testing.T) {
+	resource := Provider().ResourcesMap["contabo_private_network"]
+
+	for _, m := range []interface{}{nil, &providerMeta{}} {
+		d := schema.TestResourceDataRaw(t, resource.Schema, map[string]interface{}{})
+		d.SetId("100")
+
+		if _, err := resource.Importer.StateContext(context.Background(), d, m); err != errProviderNotConfigured {
+			t.Fatalf("expected the import to fail for meta %v, got %v", m, err)
+		}
+	}
+
+	d := schema.TestResourceDataRaw(t, resource.Schema, map[string]interface{}{
+		"wait_for_deletion": true,
+	})
+	d.SetId("100")
+	if diags := resource.DeleteWithoutTimeout(context.Background(), d, &providerMeta{}); !diags.HasError() {
+		t.Fatalf("expected deleting the Private Network to fail without client, got %v", diags)
+	}
+
+	resources := map[string]*schema.Resource{}
+	for name, resource := range Provider().ResourcesMap {
+		resources["resource "+name] = resource
+	}
+	for name, dataSource := range Provider().DataSourcesMap {
+		resources["data source "+name] = dataSource
+	}
+	for name, resource := range resources {
+		operations := map[string]func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics{
+			"CreateContext":        resource.CreateContext,
+			"CreateWithoutTimeout": resource.CreateWithoutTimeout,
+			"ReadContext":          resource.ReadContext,
+			"ReadWithoutTimeout":   resource.ReadWithoutTimeout,
+			"UpdateContext":        resource.UpdateContext,
+			"UpdateWithoutTimeout": resource.UpdateWithoutTimeout,
+			"DeleteContext":        resource.DeleteContext,
+			"DeleteWithoutTimeout": resource.DeleteWithoutTimeout,
+		}
+		for operationName, operation := range operations {
+			if operation == nil {
+				continue
+			}
+			for _, m := range []interface{}{nil, &providerMeta{}} {
+				d := schema.TestResourceDataRaw(t, resource.Schema, map[string]interface{}{})
+				d.SetId("100")
+
+				diags := operation(context.Background(), d, m)
+				if !diags.HasError() || !strings.Contains(diags[0].Summary, "provider not configured") {
+					t.Fatalf("expected %s of the %s to fail without client for meta %v, got %v", operationName, name, m, diags)
+				}
+			}
+		}
+	}
+}
@@ -92,7 +92,7 @@ func resourceImage() *schema.Resource {
 
 func resourceImageCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	createImageRequest := openapi.NewCreateCustomImageRequestWithDefaults()
 
@@ -138,7 +138,7 @@ func resourceImageCreate(ctx context.Context, d *schema.ResourceData, m interfac
 
 func resourceImageRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	imageId := d.Id()
 
@@ -158,7 +158,7 @@ func resourceImageRead(ctx context.Context, d *schema.ResourceData, m interface{
 
 func resourceImageUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 	anyChange := false
 	imageId := d.Id()
 
@@ -199,7 +199,7 @@ func resourceImageUpdate(ctx context.Context, d *schema.ResourceData, m interfac
 
 func resourceImageDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 	imageId := d.Id()
 
 	httpResp, err := client.ImagesApi.
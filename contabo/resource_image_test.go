@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"testing"
 
-	"contabo.com/openapi"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	uuid "github.com/satori/go.uuid"
@@ -28,7 +27,7 @@ func TestAccContaboImageBasic(t *testing.T) {
 }
 
 func testAccCheckImageDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*openapi.APIClient)
+	client := testAccProvider.Meta().(*providerMeta).client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "contabo_image" {
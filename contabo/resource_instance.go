@@ -2,6 +2,8 @@ package contabo
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -18,6 +20,7 @@ func resourceInstance() *schema.Resource {
 		ReadContext:   resourceInstanceRead,
 		UpdateContext: resourceInstanceUpdate,
 		DeleteContext: resourceInstanceDelete,
+		CustomizeDiff: validateInstanceProductChange,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -59,7 +62,7 @@ func resourceInstance() *schema.Resource {
 				Type:        schema.TypeString,
 				Optional:    true,
 				Computed:    true,
-				Description: "Choose the VPS/VDS product you want to buy. See our products [here](https://api.contabo.com/#tag/Instances/operation/createInstance).",
+				Description: "Choose the VPS/VDS product you want to buy. See our products [here](https://api.contabo.com/#tag/Instances/operation/createInstance). The API does not support changing the product of an existing instance, upgrades have to be ordered in the Customer Control Panel.",
 			},
 			"ip_config": {
 				Type:     schema.TypeList,
@@ -143,11 +146,12 @@ func resourceInstance() *schema.Resource {
 			"ssh_keys": {
 				Computed: true,
 				Optional: true,
+				ForceNew: true,
 				Type:     schema.TypeList,
 				Elem: &schema.Schema{
 					Type: schema.TypeInt,
 				},
-				Description: "Array of `secretIds` of public SSH keys for logging into as defaultUser with administrator/root privileges. Applies to Linux/BSD systems. Please refer to Secrets Management API.",
+				Description: "Array of `secretIds` of public SSH keys for logging into as defaultUser with administrator/root privileges. Applies to Linux/BSD systems. Please refer to Secrets Management API. The API can not change the keys of a running instance, changing them replaces the instance and all data on its disk is lost.",
 			},
 			"root_password": {
 				Optional:    true,
@@ -159,11 +163,49 @@ func resourceInstance() *schema.Resource {
 				Computed:    true,
 				Description: "The creation date of the compute instance.",
 			},
+			"tags": {
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Optional:    true,
+				Computed:    true,
+				Description: "Ids of the tags which should be assigned to the instance. If not set, the tags assigned outside of terraform are only read if `include_tags` is set.",
+			},
+			"include_tags": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, the tags assigned to the instance are read into `tags` even if `tags` is not configured. This requires listing the assignments of all tags on every refresh and is therefore disabled by default.",
+			},
+			"tags_managed": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether `tags` has been applied by terraform. Only then removing the instance from terraform unassigns its tags, tags which were only read are left assigned.",
+			},
+			"_debug_last_response":  debugLastResponseSchema(),
+			"last_read_duration_ms": debugLastReadDurationSchema(),
+			"auto_recover": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, an instance whose installation ends in the status `error` during create is reinstalled once with the same image, `ssh_keys`, `root_password` and `user_data`. All data on its disk is lost, which is none yet on create.",
+			},
+			"purge_snapshots_on_delete": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, all snapshots of the instance are deleted when the instance is removed from terraform. By default the snapshots are kept.",
+			},
 			"cancel_date": {
 				Type:        schema.TypeString,
 				Optional:    true,
 				Description: "The date on which the instance will be cancelled.",
 			},
+			"s3_url": s3UrlSchema("instance"),
+			"healthy": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the instance is `running` without an error message, e.g. as a single gate for dependent modules. The API reports neither the cloud-init progress nor the reachability of an instance, so they are not taken into account.",
+			},
 			"status": {
 				Type:        schema.TypeString,
 				Computed:    true,
@@ -208,7 +250,7 @@ func resourceInstance() *schema.Resource {
 			"user_data": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				Description: "Cloud-Init Config in order to customize during start of compute instance.",
+				Description: "Cloud-Init Config in order to customize during start of compute instance. The provider waits until the installation of the instance has finished, the API does not report whether cloud-init completed afterwards.",
 			},
 			"license": {
 				Type:        schema.TypeString,
@@ -260,7 +302,7 @@ func resourceInstance() *schema.Resource {
 
 func resourceInstanceCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	createInstanceRequest := openapi.NewCreateInstanceRequestWithDefaults()
 
@@ -322,12 +364,91 @@ func resourceInstanceCreate(ctx context.Context, d *schema.ResourceData, m inter
 
 	d.SetId(strconv.Itoa(int(res.Data[0].InstanceId)))
 
-	return resourceInstanceRead(ctx, d, m)
+	// tags can only be assigned once the instance exists
+	d.Set("tags_managed", d.Get("tags").(*schema.Set).Len() > 0)
+	diags = append(diags, tagCreatedResource(ctx, d, client, instanceTagResourceType)...)
+
+	if d.Get("auto_recover").(bool) {
+		diags = append(diags, recoverFailedInstallation(ctx, d, client, res.Data[0].InstanceId)...)
+		if diags.HasError() {
+			return diags
+		}
+	}
+	return append(diags, resourceInstanceRead(ctx, d, m)...)
+}
+
+// recoverFailedInstallation waits for the installation of the created
+// instance and reinstalls it once if it failed. The reinstall is awaited by
+// the following read.
+func recoverFailedInstallation(
+	ctx context.Context,
+	d *schema.ResourceData,
+	client *openapi.APIClient,
+	instanceId int64,
+) diag.Diagnostics {
+	instance, _, diags := pollInstanceInstalled(nil, client, ctx, instanceId)
+	if diags.HasError() || instance.Status != openapi.ERROR {
+		return diags
+	}
+
+	reinstallInstanceRequest := openapi.NewReinstallInstanceRequestWithDefaults()
+	reinstallInstanceRequest.ImageId = d.Get("image_id").(string)
+	if reinstallInstanceRequest.ImageId == "" {
+		reinstallInstanceRequest.ImageId = instance.ImageId
+	}
+	if sshKeys := d.Get("ssh_keys").([]interface{}); len(sshKeys) > 0 {
+		var sshKeys64 []int64
+		for _, key := range sshKeys {
+			sshKeys64 = append(sshKeys64, int64(key.(int)))
+		}
+		reinstallInstanceRequest.SshKeys = &sshKeys64
+	}
+	if rootPassword, ok := d.GetOk("root_password"); ok {
+		rootPassword64 := int64(rootPassword.(int))
+		reinstallInstanceRequest.RootPassword = &rootPassword64
+	}
+	if userData := d.Get("user_data").(string); userData != "" {
+		reinstallInstanceRequest.UserData = &userData
+	}
+
+	_, httpResp, err := client.InstancesApi.
+		ReinstallInstance(ctx, instanceId).
+		XRequestId(uuid.NewV4().String()).
+		ReinstallInstanceRequest(*reinstallInstanceRequest).
+		Execute()
+	if err != nil {
+		return HandleResponseErrors(diags, httpResp)
+	}
+
+	errorMessage := ""
+	if instance.ErrorMessage != nil {
+		errorMessage = *instance.ErrorMessage
+	}
+	return append(diags, diag.Diagnostic{
+		Severity: diag.Warning,
+		Summary:  "Instance reinstalled after a failed installation",
+		Detail: fmt.Sprintf("The installation of instance %d failed with the error message %q, auto_recover is set so it has been reinstalled once.",
+			instanceId, errorMessage),
+	})
+}
+
+// validateInstanceProductChange rejects product changes of existing instances
+// at plan time. The upgrade endpoint of the API only adds add-ons, changing
+// the product in the state alone would silently leave the instance as is.
+func validateInstanceProductChange(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if d.Id() == "" || !d.HasChange("product_id") {
+		return nil
+	}
+
+	old, new := d.GetChange("product_id")
+	return fmt.Errorf(
+		"the product of instance %s can not be changed from %v to %v in place, the API does not support it. Order the upgrade in the Customer Control Panel and update product_id afterwards",
+		d.Id(), old, new)
 }
 
 func resourceInstanceRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	instanceId, err := strconv.ParseInt(d.Id(), 10, 64)
 
@@ -335,18 +456,58 @@ func resourceInstanceRead(ctx context.Context, d *schema.ResourceData, m interfa
 		return diag.FromErr(err)
 	}
 
-	instance, diag := pollInstanceInstalled(diags, client, ctx, instanceId)
+	start := time.Now()
+	instance, httpResp, pollDiags := pollInstanceInstalled(diags, client, ctx, instanceId)
 
 	if err != nil || instance == nil {
-		return append(diags, diag...)
+		return append(diags, pollDiags...)
+	}
+
+	diags = AddInstanceToData(*instance, d, diags)
+	diags = AddDebugLastResponseToData(m, httpResp, d, diags)
+	diags = AddDebugReadDurationToData(m, start, d, diags)
+	if diags.HasError() {
+		return diags
+	}
+
+	diags = readInstanceTags(ctx, client, d, diags)
+	if diags.HasError() {
+		return diags
+	}
+
+	return AddDataCenterS3UrlToData(ctx, client, instance.DataCenter, d, diags)
+}
+
+// readInstanceTags reads the tags assigned to the instance if they are managed
+// by terraform or include_tags is set, as this lists the assignments of all
+// tags. Failing to read them only warns and keeps the tags of the state.
+func readInstanceTags(
+	ctx context.Context,
+	client *openapi.APIClient,
+	d *schema.ResourceData,
+	diags diag.Diagnostics,
+) diag.Diagnostics {
+	if d.Get("tags").(*schema.Set).Len() == 0 && !d.Get("tags_managed").(bool) && !d.Get("include_tags").(bool) {
+		return diags
 	}
 
-	return AddInstanceToData(*instance, d, diags)
+	tags, err := readAssignedTags(ctx, client, instanceTagResourceType, d.Id())
+	if err != nil {
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Could not read the tags of the instance",
+			Detail:   fmt.Sprintf("The tags of instance %s are read again on the next refresh: %s", d.Id(), err),
+		})
+	}
+	if err := d.Set("tags", tags); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
 }
 
 func resourceInstanceUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 	anyChange := false
 	instanceId, err := strconv.ParseInt(d.Id(), 10, 64)
 
@@ -354,21 +515,18 @@ func resourceInstanceUpdate(ctx context.Context, d *schema.ResourceData, m inter
 		return diag.FromErr(err)
 	}
 
-	patchInstanceRequest := openapi.NewReinstallInstanceRequestWithDefaults()
-
-	if d.HasChange("ssh_keys") {
-		sshKeys := d.Get("ssh_keys")
-		if sshKeys != nil {
-			var sshKeys64 []int64
-			for _, key := range sshKeys.([]interface{}) {
-				sshKey := key.(int)
-				sshKeys64 = append(sshKeys64, int64(sshKey))
-			}
-			patchInstanceRequest.SshKeys = &sshKeys64
-			anyChange = true
+	if d.HasChange("tags") {
+		if rsltDiag := updateTags(ctx, d, client, instanceTagResourceType); rsltDiag.HasError() {
+			return rsltDiag
 		}
+		d.Set("tags_managed", true)
 	}
 
+	patchInstanceRequest := openapi.NewReinstallInstanceRequestWithDefaults()
+
+	// The API offers no way to change these on a running instance, all of
+	// them are applied by reinstalling it. ssh_keys replaces the instance
+	// instead, so that the loss of its data shows in the plan.
 	if d.HasChange("root_password") {
 		rootPassword := d.Get("root_password")
 		if rootPassword != nil {
@@ -386,10 +544,13 @@ func resourceInstanceUpdate(ctx context.Context, d *schema.ResourceData, m inter
 		}
 	}
 
+	if d.HasChange("image_id") {
+		anyChange = true
+	}
+
 	imageId := d.Get("image_id").(string)
 	if imageId != "" {
 		patchInstanceRequest.ImageId = imageId
-		anyChange = true
 	}
 
 	if anyChange {
@@ -407,7 +568,15 @@ func resourceInstanceUpdate(ctx context.Context, d *schema.ResourceData, m inter
 
 		d.SetId(strconv.Itoa(int(res.Data[0].InstanceId)))
 
-		return resourceInstanceRead(ctx, d, m)
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Instance has been reinstalled",
+			Detail: fmt.Sprintf(
+				"Changes to image_id, root_password or user_data can only be applied by reinstalling, instance %d has been reinstalled and all data on its disk is lost.",
+				instanceId),
+		})
+
+		return append(diags, resourceInstanceRead(ctx, d, m)...)
 	}
 
 	return diags
@@ -415,6 +584,73 @@ func resourceInstanceUpdate(ctx context.Context, d *schema.ResourceData, m inter
 
 func resourceInstanceDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
+	client := m.(*providerMeta).client
+
+	// removing the instance from terraform does not cancel it, only unassign
+	// the tags terraform applied
+	if tags := d.Get("tags").(*schema.Set); d.Get("tags_managed").(bool) && tags.Len() > 0 {
+		_, rsltDiag := reconcileTags(
+			ctx, client, instanceTagResourceType, d.Id(), tags, schema.NewSet(schema.HashInt, nil))
+		if rsltDiag.HasError() {
+			return rsltDiag
+		}
+	}
+
+	if d.Get("purge_snapshots_on_delete").(bool) {
+		instanceId, err := strconv.ParseInt(d.Id(), 10, 64)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		return purgeInstanceSnapshots(ctx, diags, client, instanceId)
+	}
+
+	return diags
+}
+
+// snapshotListPageSize is the page size used while listing the snapshots of
+// an instance.
+const snapshotListPageSize = 100
+
+func purgeInstanceSnapshots(
+	ctx context.Context,
+	diags diag.Diagnostics,
+	client *openapi.APIClient,
+	instanceId int64,
+) diag.Diagnostics {
+	// all pages are listed before deleting, which would shift the pages
+	snapshots := []openapi.SnapshotResponse{}
+	err := paginate(snapshotListPageSize, func(page int64) (int, int64, error) {
+		res, httpResp, err := client.SnapshotsApi.
+			RetrieveSnapshotList(ctx, instanceId).
+			XRequestId(uuid.NewV4().String()).
+			Page(page).
+			Size(snapshotListPageSize).
+			Execute()
+		if err != nil {
+			return 0, 0, NewApiError(httpResp, err)
+		}
+
+		snapshots = append(snapshots, res.Data...)
+		return len(res.Data), int64(res.Pagination.TotalPages), nil
+	})
+	if err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+
+	for _, snapshot := range snapshots {
+		httpResp, err := client.SnapshotsApi.
+			DeleteSnapshot(ctx, instanceId, snapshot.SnapshotId).
+			XRequestId(uuid.NewV4().String()).
+			Execute()
+
+		if err != nil {
+			// an already removed snapshot does not need to be purged
+			if apiError, errDiags := HandleApiError(diags, httpResp, err); !apiError.IsNotFound() {
+				return errDiags
+			}
+		}
+	}
+
 	return diags
 }
 
@@ -471,6 +707,9 @@ func AddInstanceToData(
 	if err := d.Set("status", instance.Status); err != nil {
 		return diag.FromErr(err)
 	}
+	if err := d.Set("healthy", isInstanceHealthy(instance)); err != nil {
+		return diag.FromErr(err)
+	}
 	if err := d.Set("v_host_id", instance.VHostId); err != nil {
 		return diag.FromErr(err)
 	}
@@ -557,21 +796,26 @@ func buildAdditionalIps(
 	return nil
 }
 
+// isInstanceHealthy reports whether the instance is running without error.
+func isInstanceHealthy(instance openapi.InstanceResponse) bool {
+	return instance.Status == openapi.RUNNING && (instance.ErrorMessage == nil || *instance.ErrorMessage == "")
+}
+
 func pollInstanceInstalled(
 	diags diag.Diagnostics,
 	client *openapi.APIClient,
 	ctx context.Context,
 	instanceId int64,
-) (*openapi.InstanceResponse, diag.Diagnostics) {
+) (*openapi.InstanceResponse, *http.Response, diag.Diagnostics) {
 	res, httpResp, err := client.InstancesApi.
 		RetrieveInstance(ctx, instanceId).
 		XRequestId(uuid.NewV4().String()).
 		Execute()
 
 	if err != nil {
-		return nil, HandleResponseErrors(diags, httpResp)
+		return nil, httpResp, HandleResponseErrors(diags, httpResp)
 	} else if len(res.Data) != 1 {
-		return nil, MultipleDataObjectsError(diags)
+		return nil, httpResp, MultipleDataObjectsError(diags)
 	}
 
 	status := res.Data[0].Status
@@ -581,5 +825,5 @@ func pollInstanceInstalled(
 		return pollInstanceInstalled(diags, client, ctx, instanceId)
 	}
 
-	return &res.Data[0], nil
+	return &res.Data[0], httpResp, nil
 }
@@ -1,10 +1,17 @@
 package contabo
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 	"testing"
 
+	"contabo.com/openapi"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -53,3 +60,403 @@ func testCheckContaboInstanceExists(n string) resource.TestCheckFunc {
 		return nil
 	}
 }
+
+func testInstanceJson(instanceId int, status string) string {
+	return fmt.Sprintf(`{
+		"tenantId":"DE","customerId":"54321","additionalIps":[],"name":"vmd%[1]d","displayName":"",
+		"instanceId":%[1]d,"dataCenter":"European Union 1","region":"EU","regionName":"European Union",
+		"productId":"V1","imageId":"afecbb85-e2fc-46f0-9684-b46b1faf00bb",
+		"ipConfig":{"v4":{"ip":"192.0.2.10","netmaskCidr":24,"gateway":"192.0.2.1"},"v6":{"ip":"2001:db8::10","netmaskCidr":64,"gateway":"fe80::1"}},
+		"macAddress":"00:50:56:00:00:01","ramMb":8192,"cpuCores":4,"osType":"Linux","diskMb":204800,"sshKeys":[1],
+		"createdDate":"2022-01-01T00:00:00Z","cancelDate":"","status":"%[2]s","vHostId":1,"addOns":[],
+		"errorMessage":null,"productType":"ssd","defaultUser":"root"}`,
+		instanceId, status)
+}
+
+func TestInstanceSshKeysChangeReplaces(t *testing.T) {
+	state := &terraform.InstanceState{
+		ID: "12345",
+		Attributes: map[string]string{
+			"id":         "12345",
+			"ssh_keys.#": "1",
+			"ssh_keys.0": "1",
+		},
+	}
+
+	diff, err := resourceInstance().Diff(
+		context.Background(),
+		state,
+		terraform.NewResourceConfigRaw(map[string]interface{}{"ssh_keys": []interface{}{1, 2}}),
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff == nil || !diff.RequiresNew() {
+		t.Fatalf("expected a change of ssh_keys to replace the instance, got %v", diff)
+	}
+}
+
+func TestInstanceUpdateWithoutReinstallChanges(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceInstance().Schema, map[string]interface{}{
+		"cancel_date": "2030-01-01",
+	})
+	d.SetId("12345")
+
+	if diags := resourceInstanceUpdate(context.Background(), d, meta); len(diags) != 0 {
+		t.Fatalf("expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestInstanceDeletePurgesSnapshots(t *testing.T) {
+	for _, purge := range []bool{false, true} {
+		deletedSnapshots := []string{}
+		meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/12345/snapshots":
+				writeJson(w, http.StatusOK, listBody(`
+					{"tenantId":"DE","customerId":"54321","snapshotId":"snap1","name":"first","description":"",
+					"instanceId":12345,"createdDate":"2022-01-01T00:00:00Z","autoDeleteDate":"2022-02-01T00:00:00Z",
+					"imageId":"afecbb85-e2fc-46f0-9684-b46b1faf00bb","imageName":"ubuntu"},
+					{"tenantId":"DE","customerId":"54321","snapshotId":"snap2","name":"second","description":"",
+					"instanceId":12345,"createdDate":"2022-01-01T00:00:00Z","autoDeleteDate":"2022-02-01T00:00:00Z",
+					"imageId":"afecbb85-e2fc-46f0-9684-b46b1faf00bb","imageName":"ubuntu"}`, 2))
+			case r.Method == http.MethodDelete && r.URL.Path == "/v1/compute/instances/12345/snapshots/snap1":
+				deletedSnapshots = append(deletedSnapshots, "snap1")
+				w.WriteHeader(http.StatusNoContent)
+			case r.Method == http.MethodDelete && r.URL.Path == "/v1/compute/instances/12345/snapshots/snap2":
+				deletedSnapshots = append(deletedSnapshots, "snap2")
+				writeJson(w, http.StatusNotFound, `{"statusCode":404,"message":"Entry Snapshot not found"}`)
+			default:
+				t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+			}
+		}))
+
+		d := schema.TestResourceDataRaw(t, resourceInstance().Schema, map[string]interface{}{
+			"purge_snapshots_on_delete": purge,
+		})
+		d.SetId("12345")
+
+		if diags := resourceInstanceDelete(context.Background(), d, meta); diags.HasError() {
+			t.Fatalf("unexpected error with purge_snapshots_on_delete=%t: %v", purge, diags)
+		}
+
+		if !purge && len(deletedSnapshots) != 0 {
+			t.Fatalf("expected no snapshots to be deleted, got %v", deletedSnapshots)
+		}
+		if purge && len(deletedSnapshots) != 2 {
+			t.Fatalf("expected both snapshots to be deleted, got %v", deletedSnapshots)
+		}
+	}
+}
+
+func TestInstanceDeletePurgesSnapshotsOfAllPages(t *testing.T) {
+	deletedSnapshots := []string{}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var snapshotId int
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/12345/snapshots":
+			page := r.URL.Query().Get("page")
+			writeJson(w, http.StatusOK, fmt.Sprintf(
+				`{"_pagination":{"size":1,"totalElements":2,"totalPages":2,"page":%[1]s},"data":[
+				{"tenantId":"DE","customerId":"54321","snapshotId":"%[1]s","name":"page %[1]s","description":"",
+				"instanceId":12345,"createdDate":"2022-01-01T00:00:00Z","autoDeleteDate":"2022-02-01T00:00:00Z",
+				"imageId":"afecbb85-e2fc-46f0-9684-b46b1faf00bb","imageName":"ubuntu"}],"_links":{"self":"/"}}`,
+				page))
+		case r.Method == http.MethodDelete && sscanfPath(r.URL.Path, "/v1/compute/instances/12345/snapshots/%d", &snapshotId):
+			deletedSnapshots = append(deletedSnapshots, fmt.Sprint(snapshotId))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceInstance().Schema, map[string]interface{}{
+		"purge_snapshots_on_delete": true,
+	})
+	d.SetId("12345")
+
+	if diags := resourceInstanceDelete(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if fmt.Sprint(deletedSnapshots) != "[1 2]" {
+		t.Fatalf("expected the snapshots of both pages to be deleted, got %v", deletedSnapshots)
+	}
+}
+
+func TestInstanceReadWaitsForInstallation(t *testing.T) {
+	statuses := []string{"provisioning", "installing", "running"}
+	polls := 0
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/v1/tags" {
+			writeJson(w, http.StatusOK, listBody("", 0))
+			return
+		}
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/compute/instances/12345" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		status := statuses[polls]
+		polls++
+		writeJson(w, http.StatusOK, `{"data":[`+testInstanceJson(12345, status)+`],"_links":{"self":"/"}}`)
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceInstance().Schema, map[string]interface{}{})
+	d.SetId("12345")
+
+	if diags := resourceInstanceRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if polls != len(statuses) {
+		t.Fatalf("expected to poll until the installation finished, polled %d times", polls)
+	}
+	if status := d.Get("status").(string); status != "running" {
+		t.Fatalf("expected status running, got %s", status)
+	}
+}
+
+func TestInstanceReadTagsOnlyIfManaged(t *testing.T) {
+	tagReads := 0
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/12345":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceJson(12345, "running")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/tags":
+			tagReads++
+			writeJson(w, http.StatusForbidden, `{"statusCode":403,"message":"Forbidden"}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceInstance().Schema, map[string]interface{}{})
+	d.SetId("12345")
+	if diags := resourceInstanceRead(context.Background(), d, meta); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if tagReads != 0 {
+		t.Fatalf("expected no tags to be read without configured tags, read them %d times", tagReads)
+	}
+
+	d = schema.TestResourceDataRaw(t, resourceInstance().Schema, map[string]interface{}{
+		"tags": []interface{}{7},
+	})
+	d.SetId("12345")
+	diags := resourceInstanceRead(context.Background(), d, meta)
+	if diags.HasError() || len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Fatalf("expected a warning about the tags, got %v", diags)
+	}
+	if tagReads != 1 {
+		t.Fatalf("expected the configured tags to be read, read them %d times", tagReads)
+	}
+	if tags := d.Get("tags").(*schema.Set); tags.Len() != 1 || !tags.Contains(7) {
+		t.Fatalf("expected the tags of the state to be kept, got %v", tags.List())
+	}
+}
+
+func TestInstanceProductChangeIsRejected(t *testing.T) {
+	state := &terraform.InstanceState{
+		ID: "12345",
+		Attributes: map[string]string{
+			"id":         "12345",
+			"product_id": "V45",
+		},
+	}
+
+	_, err := resourceInstance().Diff(
+		context.Background(),
+		state,
+		terraform.NewResourceConfigRaw(map[string]interface{}{"product_id": "V46"}),
+		nil,
+	)
+	if err == nil || !strings.Contains(err.Error(), "can not be changed from V45 to V46") {
+		t.Fatalf("expected the product change to be rejected, got %v", err)
+	}
+
+	_, err = resourceInstance().Diff(
+		context.Background(),
+		state,
+		terraform.NewResourceConfigRaw(map[string]interface{}{"product_id": "V45"}),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("expected an unchanged product to be accepted, got %v", err)
+	}
+}
+
+func TestInstanceCreateWithTagsAndChangeThem(t *testing.T) {
+	assignedTags := map[int]bool{}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var tagId int
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/compute/instances":
+			writeJson(w, http.StatusCreated, `{"data":[{"tenantId":"DE","customerId":"54321","instanceId":12345}],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/12345":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceJson(12345, "running")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/tags":
+			writeJson(w, http.StatusOK, listBody(`
+				{"tenantId":"DE","customerId":"54321","tagId":7,"name":"web","color":"#0A78C3"},
+				{"tenantId":"DE","customerId":"54321","tagId":8,"name":"production","color":"#0A78C3"},
+				{"tenantId":"DE","customerId":"54321","tagId":9,"name":"monitored","color":"#0A78C3"}`, 3))
+		case r.Method == http.MethodGet && sscanfPath(r.URL.Path, "/v1/tags/%d/assignments", &tagId):
+			if !assignedTags[tagId] {
+				writeJson(w, http.StatusOK, listBody("", 0))
+				return
+			}
+			writeJson(w, http.StatusOK, listBody(fmt.Sprintf(`
+				{"tenantId":"DE","customerId":"54321","tagId":%d,"tagName":"",
+				"resourceType":"instance","resourceId":"12345","resourceName":"vmi12345"}`, tagId), 1))
+		case r.Method == http.MethodPost && sscanfPath(r.URL.Path, "/v1/tags/%d/assignments/instance/12345", &tagId):
+			assignedTags[tagId] = true
+			writeJson(w, http.StatusCreated, `{"_links":{"self":"/"}}`)
+		case r.Method == http.MethodDelete && sscanfPath(r.URL.Path, "/v1/tags/%d/assignments/instance/12345", &tagId):
+			delete(assignedTags, tagId)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceInstance().Schema, map[string]interface{}{
+		"tags": []interface{}{7, 8},
+	})
+	if diags := resourceInstanceCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error on create: %v", diags)
+	}
+	if !assignedTags[7] || !assignedTags[8] || len(assignedTags) != 2 {
+		t.Fatalf("expected the tags 7 and 8 to be assigned after the create, got %v", assignedTags)
+	}
+
+	state := d.State()
+	diff, err := resourceInstance().Diff(
+		context.Background(),
+		state,
+		terraform.NewResourceConfigRaw(map[string]interface{}{
+			"image_id": "afecbb85-e2fc-46f0-9684-b46b1faf00bb",
+			"tags":     []interface{}{8, 9},
+		}),
+		meta,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err = schema.InternalMap(resourceInstance().Schema).Data(state, diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diags := resourceInstanceUpdate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error on update: %v", diags)
+	}
+	if !assignedTags[8] || !assignedTags[9] || len(assignedTags) != 2 {
+		t.Fatalf("expected the tags 8 and 9 to be assigned after the update, got %v", assignedTags)
+	}
+	if tags := d.Get("tags").(*schema.Set); tags.Len() != 2 || !tags.Contains(8) || !tags.Contains(9) {
+		t.Fatalf("expected the tags 8 and 9 in the state, got %v", tags.List())
+	}
+}
+
+func TestInstanceDeleteUnassignsManagedTagsOnly(t *testing.T) {
+	for _, managed := range []bool{false, true} {
+		unassignedTags := []int{}
+		meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var tagId int
+			switch {
+			case r.Method == http.MethodGet && sscanfPath(r.URL.Path, "/v1/tags/%d/assignments", &tagId):
+				writeJson(w, http.StatusOK, listBody(fmt.Sprintf(`
+					{"tenantId":"DE","customerId":"54321","tagId":%d,"tagName":"",
+					"resourceType":"instance","resourceId":"12345","resourceName":"vmi12345"}`, tagId), 1))
+			case r.Method == http.MethodDelete && sscanfPath(r.URL.Path, "/v1/tags/%d/assignments/instance/12345", &tagId):
+				unassignedTags = append(unassignedTags, tagId)
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+			}
+		}))
+
+		d := schema.TestResourceDataRaw(t, resourceInstance().Schema, map[string]interface{}{
+			"tags": []interface{}{7},
+		})
+		d.SetId("12345")
+		d.Set("tags_managed", managed)
+
+		if diags := resourceInstanceDelete(context.Background(), d, meta); diags.HasError() {
+			t.Fatalf("unexpected error with tags_managed=%t: %v", managed, diags)
+		}
+		if !managed && len(unassignedTags) != 0 {
+			t.Fatalf("expected tags only read from the instance to stay assigned, got %v unassigned", unassignedTags)
+		}
+		if managed && fmt.Sprint(unassignedTags) != "[7]" {
+			t.Fatalf("expected the managed tag 7 to be unassigned, got %v", unassignedTags)
+		}
+	}
+}
+
+func TestInstanceCreateAutoRecoversFailedInstallation(t *testing.T) {
+	failedInstance := strings.Replace(testInstanceJson(12345, "error"), `"errorMessage":null`, `"errorMessage":"Installation failed"`, 1)
+	reinstalls := 0
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/compute/instances":
+			writeJson(w, http.StatusCreated, `{"data":[{"tenantId":"DE","customerId":"54321","instanceId":12345}],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/12345":
+			if reinstalls == 0 {
+				writeJson(w, http.StatusOK, `{"data":[`+failedInstance+`],"_links":{"self":"/"}}`)
+				return
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceJson(12345, "running")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/compute/instances/12345":
+			reinstalls++
+			var reinstall map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&reinstall); err != nil || reinstall["imageId"] != "afecbb85-e2fc-46f0-9684-b46b1faf00bb" {
+				t.Fatalf("expected a reinstall with the same image, got %v %v", reinstall, err)
+			}
+			writeJson(w, http.StatusOK, `{"data":[{"tenantId":"DE","customerId":"54321","instanceId":12345}],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/tags":
+			writeJson(w, http.StatusOK, listBody("", 0))
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceInstance().Schema, map[string]interface{}{
+		"image_id":     "afecbb85-e2fc-46f0-9684-b46b1faf00bb",
+		"auto_recover": true,
+	})
+
+	diags := resourceInstanceCreate(context.Background(), d, meta)
+	if diags.HasError() || len(diags) != 1 || !strings.Contains(diags[0].Detail, "Installation failed") {
+		t.Fatalf("expected a single warning about the recovery, got %v", diags)
+	}
+	if reinstalls != 1 {
+		t.Fatalf("expected a single reinstall, got %d", reinstalls)
+	}
+	if status := d.Get("status").(string); status != "running" {
+		t.Fatalf("expected the recovered instance to be running, got %q", status)
+	}
+}
+
+func TestInstanceHealthy(t *testing.T) {
+	errorMessage := "disk failure"
+	for _, c := range []struct {
+		instance openapi.InstanceResponse
+		healthy  bool
+	}{
+		{openapi.InstanceResponse{Status: openapi.INSTALLING}, false},
+		{openapi.InstanceResponse{Status: openapi.STOPPED}, false},
+		{openapi.InstanceResponse{Status: openapi.RUNNING, ErrorMessage: &errorMessage}, false},
+		{openapi.InstanceResponse{Status: openapi.RUNNING}, true},
+	} {
+		d := schema.TestResourceDataRaw(t, resourceInstance().Schema, map[string]interface{}{})
+		if diags := AddInstanceToData(c.instance, d, nil); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if healthy := d.Get("healthy").(bool); healthy != c.healthy {
+			t.Errorf("expected an instance with status %s to be healthy=%t, got %t", c.instance.Status, c.healthy, healthy)
+		}
+	}
+}
@@ -2,28 +2,38 @@ package contabo
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"strconv"
+	"strings"
 	"time"
 
 	"contabo.com/openapi"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	uuid "github.com/satori/go.uuid"
 )
 
+// objectStorageCapability is the capability of data centers offering Object
+// Storage.
+const objectStorageCapability = "Object-Storage"
+
 func resourceObjectStorage() *schema.Resource {
 	return &schema.Resource{
-		Description:   "Manage S3 compatible Object Storage. With the Object Storage API you can create Object Storages in different locations. Please note that you can only have one Object Storage per location. Furthermore, you can increase the amount of storage space and control the autoscaling feature which allows you to automatically perform a monthly upgrade of the disk space to the specified maximum. You might also inspect the usage. This API is not the S3 API itself. For accessing the S3 API directly or with S3 compatible tools like `aws` cli and after having created / upgraded your Object Storage please use the S3 URL from this Storage API and refer to the User Mangement API to retrieve the S3 credentials.",
-		CreateContext: resourceObjectStorageCreate,
-		ReadContext:   resourceObjectStorageRead,
-		UpdateContext: resourceObjectStorageUpgrade,
-		DeleteContext: resourceObjectStorageCancel,
+		Description:          "Manage S3 compatible Object Storage. With the Object Storage API you can create Object Storages in different locations. Please note that you can only have one Object Storage per location. Furthermore, you can increase the amount of storage space and control the autoscaling feature which allows you to automatically perform a monthly upgrade of the disk space to the specified maximum. You might also inspect the usage. This API is not the S3 API itself. For accessing the S3 API directly or with S3 compatible tools like `aws` cli and after having created / upgraded your Object Storage please use the S3 URL from this Storage API and refer to the User Mangement API to retrieve the S3 credentials.",
+		CreateWithoutTimeout: resourceObjectStorageCreate,
+		ReadContext:          resourceObjectStorageRead,
+		UpdateContext:        resourceObjectStorageUpgrade,
+		DeleteContext:        resourceObjectStorageCancel,
+		CustomizeDiff:        validateObjectStorageRegion,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: resourceObjectStorageImport,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultPollTimeout),
 		},
 		Schema: map[string]*schema.Schema{
+			"_debug_last_response":  debugLastResponseSchema(),
+			"last_read_duration_ms": debugLastReadDurationSchema(),
 			"id": {
 				Type:        schema.TypeString,
 				Computed:    true,
@@ -44,6 +54,11 @@ func resourceObjectStorage() *schema.Resource {
 				Computed:    true,
 				Description: "S3 URL to connect to your S3 compatible Object Storage.",
 			},
+			"s3_endpoint": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Host name of the S3 URL, e.g. `eu2.contabostorage.com`, to configure S3 clients like the `aws` cli or boto for the region of the Object Storage.",
+			},
 			"s3_tenant_id": {
 				Type:        schema.TypeString,
 				Computed:    true,
@@ -57,21 +72,26 @@ func resourceObjectStorage() *schema.Resource {
 			"auto_scaling": {
 				Type:     schema.TypeList,
 				Optional: true,
+				Computed: true,
+				MaxItems: 1,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"state": {
 							Type:        schema.TypeString,
 							Optional:    true,
-							Description: "Status of this object storage.  It can be set to `enabled`, `disabled` or `error`.",
+							Computed:    true,
+							Description: "Status of this object storage.  It can be set to `enabled`, `disabled` or `error`. Set it to `disabled` to turn auto-scaling off.",
 						},
 						"size_limit_tb": {
 							Type:        schema.TypeFloat,
 							Optional:    true,
+							Computed:    true,
 							Description: "Autoscaling size limit for the current object storage.",
 						},
 						"error_message": {
 							Type:        schema.TypeString,
 							Optional:    true,
+							Computed:    true,
 							Description: "If the autoscaling is in an error state (see status property), the error message can be seen in this field.",
 						},
 					},
@@ -90,12 +110,28 @@ func resourceObjectStorage() *schema.Resource {
 			"data_center": {
 				Type:        schema.TypeString,
 				Computed:    true,
-				Description: "Data center the object storage is located in.",
+				Description: "Data center the object storage is located in. The API places the Object Storage in a data center of the `region` itself.",
+			},
+			"display_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The display name of the Object Storage. If not set, the name chosen by Contabo is kept.",
 			},
 			"region": {
 				Type:        schema.TypeString,
 				Required:    true,
-				Description: "Region where the Object Storage should be located. Default region is the EU. Following regions are available: `EU`,`US-central`, `SIN`.",
+				Description: "Region where the Object Storage should be located. Default region is the EU. Following regions are available: `EU`,`US-central`, `SIN`. A region without a data center offering Object Storage is rejected when planning.",
+			},
+			"used_space_tb": {
+				Type:        schema.TypeFloat,
+				Computed:    true,
+				Description: "Currently used space of the Object Storage in terabyte.",
+			},
+			"used_space_percentage": {
+				Type:        schema.TypeFloat,
+				Computed:    true,
+				Description: "Currently used space of the Object Storage in percent of the purchased space.",
 			},
 			"total_purchased_space_tb": {
 				Type:        schema.TypeFloat,
@@ -106,39 +142,74 @@ func resourceObjectStorage() *schema.Resource {
 	}
 }
 
+// resourceObjectStorageImport imports the Object Storage by its id or, as
+// there is at most one Object Storage per region, by its region, e.g. `EU`.
+func resourceObjectStorageImport(
+	ctx context.Context,
+	d *schema.ResourceData,
+	m interface{},
+) ([]*schema.ResourceData, error) {
+	client := m.(*providerMeta).client
+
+	region := findRegion(d.Id())
+	if region == nil {
+		return []*schema.ResourceData{d}, nil
+	}
+
+	res, httpResp, err := client.ObjectStoragesApi.
+		RetrieveObjectStorageList(ctx).
+		XRequestId(uuid.NewV4().String()).
+		Region(region.slug).
+		Execute()
+	if err != nil {
+		return nil, NewApiError(httpResp, err)
+	}
+
+	// cancelled Object Storages are still listed until they are removed
+	var matches []openapi.ObjectStorageResponse
+	for _, objectStorage := range res.Data {
+		if objectStorage.Status != "CANCELLED" {
+			matches = append(matches, objectStorage)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no Object Storage found in region %q", region.slug)
+	case 1:
+		d.SetId(matches[0].ObjectStorageId)
+		return []*schema.ResourceData{d}, nil
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, objectStorage := range matches {
+			ids = append(ids, objectStorage.ObjectStorageId)
+		}
+		return nil, fmt.Errorf(
+			"region %q is ambiguous, it has the Object Storages %v. Please import by id instead",
+			region.slug, ids)
+	}
+}
+
 func resourceObjectStorageCreate(
 	ctx context.Context,
 	data *schema.ResourceData,
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	var err error
 
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	objectStorageRegion := data.Get("region").(string)
 	objectStorageTotalPurchasedSpaceTB := data.Get("total_purchased_space_tb").(float64)
-	objectStorageAutoScaling, _ := StructToMap(data.Get("auto_scaling"))
-
-	if err != nil {
-		return diag.FromErr(err)
-	}
 
 	createObjectStorageRequest := openapi.NewCreateObjectStorageRequestWithDefaults()
 	createObjectStorageRequest.TotalPurchasedSpaceTB = objectStorageTotalPurchasedSpaceTB
 	createObjectStorageRequest.Region = objectStorageRegion
 
-	if objectStorageAutoScaling != nil {
-		autoScalingState := fmt.Sprintf("%v", objectStorageAutoScaling["state"])
-		autoScalingLimit := fmt.Sprintf("%v", objectStorageAutoScaling["size_limit_tb"])
-		autoScalingLimitFloat, err := strconv.ParseFloat(autoScalingLimit, 64)
-		if err != nil {
-			return diag.FromErr(err)
-		}
-
+	if autoScalingState, autoScalingLimit, ok := getAutoScaling(data); ok {
 		autoScaling := openapi.AutoScalingTypeRequest{
 			State:       autoScalingState,
-			SizeLimitTB: autoScalingLimitFloat,
+			SizeLimitTB: autoScalingLimit,
 		}
 		createObjectStorageRequest.AutoScaling = &autoScaling
 	}
@@ -161,7 +232,90 @@ func resourceObjectStorageCreate(
 
 	data.SetId(res.Data[0].ObjectStorageId)
 
-	return resourceObjectStorageRead(ctx, data, m)
+	timeout := waitTimeout(data, m, schema.TimeoutCreate, defaultPollTimeout)
+	if err := waitForObjectStorageReady(ctx, client, data.Id(), m.(*providerMeta).pollInterval, timeout); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+
+	// the display name can only be set once the Object Storage exists
+	if displayName, ok := data.GetOk("display_name"); ok {
+		if err := updateObjectStorageDisplayName(ctx, client, data.Id(), displayName.(string)); err != nil {
+			return append(diag.FromErr(err), resourceObjectStorageRead(ctx, data, m)...)
+		}
+	}
+
+	return append(diags, resourceObjectStorageRead(ctx, data, m)...)
+}
+
+// waitForObjectStorageReady waits until the Object Storage is provisioned. A
+// failed provisioning is returned as error.
+func waitForObjectStorageReady(
+	ctx context.Context,
+	client *openapi.APIClient,
+	objectStorageId string,
+	pollInterval time.Duration,
+	timeout time.Duration,
+) error {
+	stateChangeConf := &resource.StateChangeConf{
+		Pending:      []string{"PROVISIONING"},
+		Target:       []string{"READY"},
+		Timeout:      timeout,
+		PollInterval: pollInterval,
+		Refresh: func() (interface{}, string, error) {
+			res, httpResp, err := client.ObjectStoragesApi.
+				RetrieveObjectStorage(ctx, objectStorageId).
+				XRequestId(uuid.NewV4().String()).
+				Execute()
+			if err != nil {
+				return nil, "", NewApiError(httpResp, err)
+			}
+			if len(res.Data) != 1 {
+				return nil, "", fmt.Errorf("Internal Error: should have returned only one object")
+			}
+
+			status := res.Data[0].Status
+			if status == "ERROR" {
+				return nil, status, fmt.Errorf("provisioning of the Object Storage %s failed", objectStorageId)
+			}
+			return res.Data[0], status, nil
+		},
+	}
+
+	_, err := stateChangeConf.WaitForStateContext(ctx)
+	return err
+}
+
+// validateObjectStorageRegion rejects regions none of whose data centers offer
+// Object Storage at plan time, listing the regions that do. Regions unknown to
+// the provider are left to the API.
+func validateObjectStorageRegion(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	regionSlug := d.Get("region").(string)
+	requested := findRegion(regionSlug)
+	if !d.NewValueKnown("region") || !d.HasChange("region") || requested == nil {
+		return nil
+	}
+
+	dataCenters, err := listDataCenters(ctx, m.(*providerMeta).client)
+	if err != nil {
+		return err
+	}
+
+	supported := []string{}
+	for _, region := range regions {
+		for _, dataCenter := range dataCenters {
+			if !strings.HasPrefix(dataCenter.Name, region.dataCenterPrefix) || !hasCapability(dataCenter, objectStorageCapability) {
+				continue
+			}
+			if region.slug == requested.slug {
+				return nil
+			}
+			supported = append(supported, region.slug)
+			break
+		}
+	}
+	return fmt.Errorf(
+		"region %q does not offer Object Storage, it is offered in %s",
+		regionSlug, strings.Join(supported, ", "))
 }
 
 func resourceObjectStorageRead(
@@ -170,10 +324,11 @@ func resourceObjectStorageRead(
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	objectStorageId := data.Id()
 
+	start := time.Now()
 	res, httpResp, err := client.
 		ObjectStoragesApi.
 		RetrieveObjectStorage(ctx, objectStorageId).
@@ -190,7 +345,14 @@ func resourceObjectStorageRead(
 		})
 	}
 
-	return AddObjectStorageToData(res.Data[0], data, diags)
+	diags = AddObjectStorageToData(res.Data[0], data, diags)
+	diags = AddDebugLastResponseToData(m, httpResp, data, diags)
+	diags = AddDebugReadDurationToData(m, start, data, diags)
+	if diags.HasError() {
+		return diags
+	}
+
+	return AddObjectStorageStatsToData(ctx, client, objectStorageId, data, diags)
 }
 
 func resourceObjectStorageUpgrade(
@@ -199,7 +361,7 @@ func resourceObjectStorageUpgrade(
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 	anyChange := false
 
 	objectStorageId := data.Id()
@@ -213,30 +375,27 @@ func resourceObjectStorageUpgrade(
 	}
 
 	if data.HasChange("auto_scaling") {
-		objectStorageAutoScaling, _ := StructToMap(data.Get("auto_scaling"))
-		autoScalingState := fmt.Sprintf("%v", objectStorageAutoScaling["state"])
-		autoScalingLimit := fmt.Sprintf("%v", objectStorageAutoScaling["size_limit_tb"])
-		autoScalingLimitFloat, err := strconv.ParseFloat(autoScalingLimit, 64)
-		if err != nil {
-			return diag.FromErr(err)
+		autoScalingState, autoScalingLimit, _ := getAutoScaling(data)
+		if autoScalingState == "" {
+			autoScalingState = autoScalingDisabled
 		}
-		autoScaling := openapi.UpgradeAutoScalingType{}
-		if autoScalingState != "" && autoScalingLimitFloat != 0 {
-			autoScaling = openapi.UpgradeAutoScalingType{
-				State:       &autoScalingState,
-				SizeLimitTB: &autoScalingLimitFloat,
-			}
-		} else if autoScalingState != "" && autoScalingLimitFloat == 0 {
-			autoScaling = openapi.UpgradeAutoScalingType{
-				State: &autoScalingState,
-			}
-		} else if autoScalingState == "" && autoScalingLimitFloat != 0 {
-			autoScaling = openapi.UpgradeAutoScalingType{
-				SizeLimitTB: &autoScalingLimitFloat,
-			}
+
+		autoScaling := openapi.UpgradeAutoScalingType{
+			State: &autoScalingState,
+		}
+		if autoScalingLimit != 0 {
+			autoScaling.SizeLimitTB = &autoScalingLimit
 		}
 
 		upgradeObjectStoragaRequest.AutoScaling = &autoScaling
+		anyChange = true
+	}
+
+	// the display name is not part of an upgrade
+	if data.HasChange("display_name") {
+		if err := updateObjectStorageDisplayName(ctx, client, objectStorageId, data.Get("display_name").(string)); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	if anyChange {
@@ -255,13 +414,31 @@ func resourceObjectStorageUpgrade(
 	return resourceObjectStorageRead(ctx, data, m)
 }
 
+// updateObjectStorageDisplayName renames the Object Storage.
+func updateObjectStorageDisplayName(
+	ctx context.Context,
+	client *openapi.APIClient,
+	objectStorageId string,
+	displayName string,
+) error {
+	_, httpResp, err := client.ObjectStoragesApi.
+		UpdateObjectStorage(ctx, objectStorageId).
+		XRequestId(uuid.NewV4().String()).
+		PatchObjectStorageRequest(*openapi.NewPatchObjectStorageRequest(displayName)).
+		Execute()
+	if err != nil {
+		return NewApiError(httpResp, err)
+	}
+	return nil
+}
+
 func resourceObjectStorageCancel(
 	ctx context.Context,
 	data *schema.ResourceData,
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	objectStorageId := data.Id()
 
@@ -302,6 +479,9 @@ func AddObjectStorageToData(
 	if err := d.Set("s3_url", objectStorage.S3Url); err != nil {
 		return diag.FromErr(err)
 	}
+	if err := d.Set("s3_endpoint", s3Endpoint(objectStorage.S3Url)); err != nil {
+		return diag.FromErr(err)
+	}
 	if err := d.Set("s3_tenant_id", objectStorage.S3TenantId); err != nil {
 		return diag.FromErr(err)
 	}
@@ -314,6 +494,9 @@ func AddObjectStorageToData(
 	if err := d.Set("region", objectStorage.Region); err != nil {
 		return diag.FromErr(err)
 	}
+	if err := d.Set("display_name", objectStorage.DisplayName); err != nil {
+		return diag.FromErr(err)
+	}
 	if err := d.Set("total_purchased_space_tb", objectStorage.TotalPurchasedSpaceTB); err != nil {
 		return diag.FromErr(err)
 	}
@@ -325,6 +508,36 @@ func AddObjectStorageToData(
 	return diags
 }
 
+// AddObjectStorageStatsToData sets the usage of the Object Storage, which the
+// API only reports via its own statistics endpoint.
+func AddObjectStorageStatsToData(
+	ctx context.Context,
+	client *openapi.APIClient,
+	objectStorageId string,
+	d *schema.ResourceData,
+	diags diag.Diagnostics,
+) diag.Diagnostics {
+	res, httpResp, err := client.ObjectStoragesApi.
+		RetrieveObjectStoragesStats(ctx, objectStorageId).
+		XRequestId(uuid.NewV4().String()).
+		Execute()
+	if err != nil {
+		return HandleResponseErrors(diags, httpResp)
+	}
+
+	if len(res.Data) != 1 {
+		return MultipleDataObjectsError(diags)
+	}
+
+	if err := d.Set("used_space_tb", float64(res.Data[0].UsedSpaceTB)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("used_space_percentage", float64(res.Data[0].UsedSpacePercentage)); err != nil {
+		return diag.FromErr(err)
+	}
+	return diags
+}
+
 func BuildAutoScaling(autoScalingResponse *openapi.AutoScalingTypeResponse) interface{} {
 	if autoScalingResponse != nil {
 		autoScaling := make(map[string]interface{})
@@ -338,11 +551,18 @@ func BuildAutoScaling(autoScalingResponse *openapi.AutoScalingTypeResponse) inte
 	return nil
 }
 
-func StructToMap(obj interface{}) (newMap map[string]interface{}, err error) {
-	data, err := json.Marshal(obj) // Convert to a json string
-	if err != nil {
-		return
+// autoScalingDisabled is the auto-scaling state sent when the auto_scaling
+// block has no state.
+const autoScalingDisabled = "disabled"
+
+// getAutoScaling returns the configured auto-scaling state and size limit, ok
+// is false if there is no auto_scaling block.
+func getAutoScaling(data *schema.ResourceData) (state string, sizeLimitTB float64, ok bool) {
+	autoScalingList := data.Get("auto_scaling").([]interface{})
+	if len(autoScalingList) == 0 || autoScalingList[0] == nil {
+		return "", 0, false
 	}
-	err = json.Unmarshal(data, &newMap) // Convert to a map
-	return
+
+	autoScaling := autoScalingList[0].(map[string]interface{})
+	return autoScaling["state"].(string), autoScaling["size_limit_tb"].(float64), true
 }
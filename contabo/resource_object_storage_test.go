@@ -1,10 +1,16 @@
 package contabo
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
+	"testing"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
@@ -57,3 +63,298 @@ func testCheckContaboObjectStorageExists(n string) resource.TestCheckFunc {
 		return nil
 	}
 }
+
+func testObjectStorageJson(objectStorageId string) string {
+	return fmt.Sprintf(`{
+		"tenantId":"DE","customerId":"54321","objectStorageId":"%[1]s","createdDate":"2022-01-01T00:00:00Z",
+		"cancelDate":"","autoScaling":{"state":"enabled","sizeLimitTB":4,"errorMessage":""},
+		"dataCenter":"European Union 2","totalPurchasedSpaceTB":2,"s3Url":"https://eu2.contabostorage.com",
+		"s3TenantId":"abc","status":"READY","region":"EU","displayName":"%[1]s"}`,
+		objectStorageId)
+}
+
+func TestObjectStorageReadUsage(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/object-storages/os1":
+			writeJson(w, http.StatusOK, `{"data":[`+testObjectStorageJson("os1")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/object-storages/os1/stats":
+			writeJson(w, http.StatusOK, `{"data":[{"usedSpaceTB":1.5,"usedSpacePercentage":75,"numberOfObjects":42}],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceObjectStorage().Schema, map[string]interface{}{})
+	d.SetId("os1")
+
+	if diags := resourceObjectStorageRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if usedSpace := d.Get("used_space_tb").(float64); usedSpace != 1.5 {
+		t.Fatalf("expected used_space_tb to be 1.5, got %v", usedSpace)
+	}
+	if usedPercentage := d.Get("used_space_percentage").(float64); usedPercentage != 75 {
+		t.Fatalf("expected used_space_percentage to be 75, got %v", usedPercentage)
+	}
+}
+
+func TestObjectStorageReadSetsS3EndpointOfRegion(t *testing.T) {
+	usObjectStorage := strings.NewReplacer(
+		`"dataCenter":"European Union 2"`, `"dataCenter":"United States Central 1"`,
+		`"s3Url":"https://eu2.contabostorage.com"`, `"s3Url":"https://usc1.contabostorage.com"`,
+		`"region":"EU"`, `"region":"US-central"`,
+	).Replace(testObjectStorageJson("os2"))
+
+	for objectStorageId, expected := range map[string]string{"os1": "eu2.contabostorage.com", "os2": "usc1.contabostorage.com"} {
+		meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/v1/object-storages/os1":
+				writeJson(w, http.StatusOK, `{"data":[`+testObjectStorageJson("os1")+`],"_links":{"self":"/"}}`)
+			case r.URL.Path == "/v1/object-storages/os2":
+				writeJson(w, http.StatusOK, `{"data":[`+usObjectStorage+`],"_links":{"self":"/"}}`)
+			case strings.HasSuffix(r.URL.Path, "/stats"):
+				writeJson(w, http.StatusOK, `{"data":[{"usedSpaceTB":0,"usedSpacePercentage":0,"numberOfObjects":0}],"_links":{"self":"/"}}`)
+			default:
+				t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+			}
+		}))
+
+		d := schema.TestResourceDataRaw(t, resourceObjectStorage().Schema, map[string]interface{}{})
+		d.SetId(objectStorageId)
+
+		if diags := resourceObjectStorageRead(context.Background(), d, meta); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if endpoint := d.Get("s3_endpoint").(string); endpoint != expected {
+			t.Fatalf("expected the S3 endpoint %s for the region %s, got %s", expected, d.Get("region"), endpoint)
+		}
+	}
+}
+
+func TestObjectStorageToggleAutoScaling(t *testing.T) {
+	var upgradeRequest map[string]interface{}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/object-storages/os1/resize":
+			upgradeRequest = nil
+			if err := json.NewDecoder(r.Body).Decode(&upgradeRequest); err != nil {
+				t.Fatal(err)
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testObjectStorageJson("os1")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/object-storages/os1":
+			writeJson(w, http.StatusOK, `{"data":[`+testObjectStorageJson("os1")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/object-storages/os1/stats":
+			writeJson(w, http.StatusOK, `{"data":[{"usedSpaceTB":1.5,"usedSpacePercentage":75,"numberOfObjects":42}],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	for _, c := range []struct {
+		autoScaling map[string]interface{}
+		state       string
+		sizeLimitTB interface{}
+	}{
+		{map[string]interface{}{"state": "enabled", "size_limit_tb": 4}, "enabled", float64(4)},
+		{map[string]interface{}{"state": "disabled"}, "disabled", nil},
+	} {
+		d := schema.TestResourceDataRaw(t, resourceObjectStorage().Schema, map[string]interface{}{
+			"region":                   "EU",
+			"total_purchased_space_tb": 2,
+			"auto_scaling":             []interface{}{c.autoScaling},
+		})
+		d.SetId("os1")
+
+		if diags := resourceObjectStorageUpgrade(context.Background(), d, meta); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+
+		if upgradeRequest == nil {
+			t.Fatalf("expected auto-scaling %s to be sent to the API", c.state)
+		}
+		autoScaling, _ := upgradeRequest["autoScaling"].(map[string]interface{})
+		if autoScaling["state"] != c.state || autoScaling["sizeLimitTB"] != c.sizeLimitTB {
+			t.Fatalf("expected state %s and size limit %v, got %v", c.state, c.sizeLimitTB, autoScaling)
+		}
+	}
+}
+
+func TestObjectStorageRenameInPlace(t *testing.T) {
+	displayName := "os1"
+	objectStorageJson := func() string {
+		return strings.Replace(testObjectStorageJson("os1"), `"displayName":"os1"`, `"displayName":"`+displayName+`"`, 1)
+	}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPatch && r.URL.Path == "/v1/object-storages/os1":
+			var patchRequest map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&patchRequest); err != nil {
+				t.Fatal(err)
+			}
+			displayName = patchRequest["displayName"].(string)
+			writeJson(w, http.StatusOK, `{"data":[`+objectStorageJson()+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/object-storages/os1/resize":
+			writeJson(w, http.StatusOK, `{"data":[`+objectStorageJson()+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/object-storages/os1":
+			writeJson(w, http.StatusOK, `{"data":[`+objectStorageJson()+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/object-storages/os1/stats":
+			writeJson(w, http.StatusOK, `{"data":[{"usedSpaceTB":1.5,"usedSpacePercentage":75,"numberOfObjects":42}],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceObjectStorage().Schema, map[string]interface{}{
+		"region":                   "EU",
+		"total_purchased_space_tb": 2,
+		"display_name":             "backups",
+	})
+	d.SetId("os1")
+
+	if diags := resourceObjectStorageUpgrade(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if displayName != "backups" {
+		t.Fatalf("expected the Object Storage to be renamed, got %q", displayName)
+	}
+	if d.Id() != "os1" || d.Get("display_name").(string) != "backups" {
+		t.Fatalf("expected the renamed Object Storage os1 to be read back, got %q (%q)", d.Get("display_name"), d.Id())
+	}
+}
+
+func TestObjectStorageImportByRegion(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/object-storages" || r.URL.Query().Get("region") != "EU" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		cancelled := strings.Replace(testObjectStorageJson("os0"), `"READY"`, `"CANCELLED"`, 1)
+		writeJson(w, http.StatusOK, listBody(cancelled+","+testObjectStorageJson("os1"), 2))
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceObjectStorage().Schema, map[string]interface{}{})
+	d.SetId("europe")
+
+	imported, err := resourceObjectStorageImport(context.Background(), d, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(imported) != 1 || imported[0].Id() != "os1" {
+		t.Fatalf("expected object storage os1 to be imported, got %v", imported[0].Id())
+	}
+}
+
+func TestObjectStorageImportByAmbiguousRegion(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, listBody(testObjectStorageJson("os1")+","+testObjectStorageJson("os2"), 2))
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceObjectStorage().Schema, map[string]interface{}{})
+	d.SetId("EU")
+
+	if _, err := resourceObjectStorageImport(context.Background(), d, meta); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Fatalf("expected an ambiguous region error, got %v", err)
+	}
+}
+
+func TestObjectStorageImportById(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("import by id must not call the API, got %s %s", r.Method, r.URL)
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceObjectStorage().Schema, map[string]interface{}{})
+	d.SetId("2f3a7b8c-1d4e-4f5a-9b6c-7d8e9f0a1b2c")
+
+	imported, err := resourceObjectStorageImport(context.Background(), d, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(imported) != 1 || imported[0].Id() != "2f3a7b8c-1d4e-4f5a-9b6c-7d8e9f0a1b2c" {
+		t.Fatalf("expected the id to be kept, got %v", imported[0].Id())
+	}
+}
+
+func TestObjectStorageRejectsRegionWithoutObjectStorage(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+	}))
+
+	_, err := resourceObjectStorage().Diff(
+		context.Background(),
+		nil,
+		terraform.NewResourceConfigRaw(map[string]interface{}{
+			"region":                   "SIN",
+			"total_purchased_space_tb": 2,
+		}),
+		meta,
+	)
+	if err == nil || !strings.Contains(err.Error(), `region "SIN" does not offer Object Storage, it is offered in EU, US-central`) {
+		t.Fatalf("expected SIN to be rejected with the regions offering Object Storage, got %v", err)
+	}
+
+	_, err = resourceObjectStorage().Diff(
+		context.Background(),
+		nil,
+		terraform.NewResourceConfigRaw(map[string]interface{}{
+			"region":                   "US-central",
+			"total_purchased_space_tb": 2,
+		}),
+		meta,
+	)
+	if err != nil {
+		t.Fatalf("expected US-central to be accepted, got %v", err)
+	}
+}
+
+func TestObjectStorageCreateWaitsUntilReady(t *testing.T) {
+	for _, c := range []struct {
+		statuses []string
+		fails    bool
+	}{
+		{[]string{"PROVISIONING", "PROVISIONING", "READY"}, false},
+		{[]string{"PROVISIONING", "ERROR"}, true},
+	} {
+		polls := 0
+		objectStorageJson := func() string {
+			status := c.statuses[len(c.statuses)-1]
+			if polls < len(c.statuses) {
+				status = c.statuses[polls]
+			}
+			return strings.Replace(testObjectStorageJson("os1"), `"status":"READY"`, `"status":"`+status+`"`, 1)
+		}
+		meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == "/v1/object-storages":
+				writeJson(w, http.StatusCreated, `{"data":[`+objectStorageJson()+`],"_links":{"self":"/"}}`)
+			case r.Method == http.MethodGet && r.URL.Path == "/v1/object-storages/os1":
+				writeJson(w, http.StatusOK, `{"data":[`+objectStorageJson()+`],"_links":{"self":"/"}}`)
+				polls++
+			case r.Method == http.MethodGet && r.URL.Path == "/v1/object-storages/os1/stats":
+				writeJson(w, http.StatusOK, `{"data":[{"usedSpaceTB":0,"usedSpacePercentage":0,"numberOfObjects":0}],"_links":{"self":"/"}}`)
+			default:
+				t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+			}
+		}))
+		meta.pollInterval = time.Millisecond
+
+		d := schema.TestResourceDataRaw(t, resourceObjectStorage().Schema, map[string]interface{}{
+			"region":                   "EU",
+			"total_purchased_space_tb": 2,
+		})
+		diags := resourceObjectStorageCreate(context.Background(), d, meta)
+
+		if c.fails {
+			if !diags.HasError() || !strings.Contains(fmt.Sprint(diags), "provisioning of the Object Storage os1 failed") {
+				t.Fatalf("expected the failed provisioning to be reported, got %v", diags)
+			}
+			continue
+		}
+		if diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if polls < 3 || d.Get("status").(string) != "READY" {
+			t.Fatalf("expected the create to wait until the Object Storage is ready, got %q after %d polls", d.Get("status"), polls)
+		}
+	}
+}
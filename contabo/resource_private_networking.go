@@ -2,28 +2,37 @@ package contabo
 
 import (
 	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"contabo.com/openapi"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	uuid "github.com/satori/go.uuid"
 )
 
-var httpConflict string = "409 Conflict"
-
 func resourcePrivateNetwork() *schema.Resource {
 	return &schema.Resource{
-		Description:   "Provides a Contabo [Private Network](https://api.contabo.com/#tag/Private-Networks) resource.  Private Networks can contain your compute instances whereby they are able to communicate with each other in full usolation, using private IP addresses.",
-		CreateContext: resourcePrivateNetworkCreate,
-		ReadContext:   resourcePrivateNetworkRead,
-		UpdateContext: resourcePrivateNetworkUpdate,
-		DeleteContext: resourcePrivateNetworkDelete,
+		Description:          "Provides a Contabo [Private Network](https://api.contabo.com/#tag/Private-Networks) resource.  Private Networks can contain your compute instances whereby they are able to communicate with each other in full usolation, using private IP addresses. Updates are applied in steps, first the instance changes, then the tags and finally the name and description. If a step fails, the steps which succeeded are kept in the state.",
+		CreateContext:        resourcePrivateNetworkCreate,
+		ReadContext:          resourcePrivateNetworkRefresh,
+		UpdateContext:        resourcePrivateNetworkUpdate,
+		DeleteWithoutTimeout: resourcePrivateNetworkDelete,
+		CustomizeDiff:        validatePrivateNetworkCapacity,
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: resourcePrivateNetworkImport,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(defaultPollTimeout),
 		},
 		Schema: map[string]*schema.Schema{
 			"created_date": {
@@ -32,17 +41,37 @@ func resourcePrivateNetwork() *schema.Resource {
 				Computed:    true,
 				Description: "The creation date of the Private Network.",
 			},
+			"created_by": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The customer number of the account which created the Private Network. The API does not tell which user of the account created it.",
+			},
 			"updated_at": {
 				Type:        schema.TypeString,
 				Optional:    true,
 				Computed:    true,
 				Description: "Time of the last update of the private network.",
 			},
+			"created_date_rfc3339": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The creation date of the Private Network in RFC 3339 format, e.g. `2022-01-01T00:00:00Z`, which unlike `created_date` can be parsed by `timeadd` and other functions.",
+			},
+			"updated_at_rfc3339": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Time of the last update of the Private Network in RFC 3339 format, like `updated_at`.",
+			},
 			"id": {
 				Type:        schema.TypeString,
 				Computed:    true,
 				Description: "The identifier of the Private Network. Use it to manage it!",
 			},
+			"private_network_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The identifier of the Private Network as a number, e.g. for scripts calling the API.",
+			},
 			"name": {
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -51,13 +80,64 @@ func resourcePrivateNetwork() *schema.Resource {
 			"description": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				Description: "The description of the Private Network. There is a limit of 255 characters per Private Network.",
+				Computed:    true,
+				Description: "The description of the Private Network. There is a limit of 255 characters per Private Network. If not set, the `default_description` of the provider is used.",
+			},
+			"adopt_existing": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, an existing Private Network with exactly the configured name is adopted instead of creating a new one. The configured description and instances are applied to it. Creating fails if the name matches more than one Private Network.",
+			},
+			"deletion_protection": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, deleting the Private Network fails. Set it to `false` and apply before destroying the Private Network.",
+			},
+			"_debug_last_response":  debugLastResponseSchema(),
+			"last_read_duration_ms": debugLastReadDurationSchema(),
+			"check_addons": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, refreshing warns about instances in the Private Network whose private networking add-on was removed outside of Terraform. They are dropped from `instance_ids` in the state, so that the next apply adds the add-on again.",
+			},
+			"include_instance_details": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, the details of the instances which the Private Network does not report, i.e. `image_id`, `os_type`, `region` and `has_private_networking_addon`, are read. This requires reading every instance and is therefore disabled by default. `check_addons` reads them as well.",
+			},
+			"assign_on_create_only": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, `instance_ids` is only applied on create. Later changes of it are ignored with a warning, so that instances assigned or unassigned in the Customer Control Panel are not reverted.",
+			},
+			"ignore_instances": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, the membership of the Private Network is managed outside of Terraform. `instance_ids` is only applied on create, refreshing keeps it as configured instead of reading the actual instances and changes of it are not applied. `instances` still lists the actual instances.",
+			},
+			"instance_order": {
+				Type:        schema.TypeList,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Optional:    true,
+				Description: "Instances of `instance_ids` to assign first and in the given order, e.g. the control plane nodes of a cluster. The other instances are assigned afterwards in no particular order. Instances not in `instance_ids` are ignored.",
+			},
+			"allow_empty": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "If set to `false`, creating the Private Network fails if `instance_ids` is empty, e.g. to catch a misconfiguration in CI.",
 			},
 			"instance_ids": {
 				Type:        schema.TypeSet,
 				Elem:        &schema.Schema{Type: schema.TypeInt},
 				Optional:    true,
-				Description: "Add the instace Ids to the private network here. If you do not add any instance Ids an empty private network will be created.",
+				Description: "Add the instace Ids to the private network here. If you do not add any instance Ids an empty private network will be created. Once the cidr range is assigned, a plan with more instances than it has addresses for besides the gateway fails.",
 			},
 			"instances": {
 				Type:     schema.TypeList,
@@ -117,6 +197,26 @@ func resourcePrivateNetwork() *schema.Resource {
 							Computed:    true,
 							Description: "State of the instance in the Private Network. The status can be one of 'ok', 'restart', 'reinstall', 'reinstallation failed', 'installing'",
 						},
+						"image_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The identifier of the image the instance runs. Only read if `include_instance_details` or `check_addons` is set.",
+						},
+						"os_type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The type of the operating system the instance runs, e.g. `Linux` or `Windows`. Only read if `include_instance_details` or `check_addons` is set.",
+						},
+						"region": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The region the instance is located in, e.g. `EU`. An instance of another region than the Private Network can not reach it. Only read if `include_instance_details` or `check_addons` is set.",
+						},
+						"has_private_networking_addon": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the instance has the private networking add-on, which is required to assign it to a Private Network. Only read if `include_instance_details` or `check_addons` is set.",
+						},
 						"error_message": {
 							Type:        schema.TypeString,
 							Computed:    true,
@@ -125,11 +225,32 @@ func resourcePrivateNetwork() *schema.Resource {
 					},
 				},
 			},
+			"last_request_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The request id of the last create or update call of the Private Network, e.g. for auditing.",
+			},
+			"s3_url": s3UrlSchema("Private Network"),
+			"tags":   tagsSchema("Private Network"),
+			"wait_for_private_ip": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, assigning instances waits until each of them has a private IPv4 address, as the API may return an assigned instance without it for a while. The wait is limited by the `poll_timeout` of the provider.",
+			},
+			"wait_for_deletion": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "If set, deleting waits until the Private Network is gone, so that dependent resources can be destroyed safely afterwards. The API is checked every `poll_interval` of the provider, at most for the `delete` timeout or the `poll_timeout` of the provider.",
+			},
+			// no Default, so that an imported Private Network keeps its actual
+			// region without a diff while region is not configured
 			"region": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				Default:     "EU",
-				Description: "The region where the Private Network should be located. Default region is the EU.",
+				Computed:    true,
+				Description: "The region where the Private Network should be located. Default region is the EU. Imported Private Networks keep their actual region if it is not set.",
 			},
 			"region_name": {
 				Type:        schema.TypeString,
@@ -152,36 +273,158 @@ func resourcePrivateNetwork() *schema.Resource {
 				Computed:    true,
 				Description: "The cidr range of the Private Network.",
 			},
+			"cidr_first_ip": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The first usable host address of the cidr range. For a /31 range both addresses are usable, a /32 range is a single host. Empty as long as no cidr range is assigned.",
+			},
+			"cidr_last_ip": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The last usable host address of the cidr range, i.e. the one before the broadcast address. Empty as long as no cidr range is assigned.",
+			},
+			"cidr_host_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The number of usable host addresses of the cidr range, e.g. `1022` for a /22 range. `0` as long as no cidr range is assigned.",
+			},
+			"network_gateway": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The gateway of the Private Network, i.e. the first usable address of its cidr range. Empty as long as no cidr range is assigned.",
+			},
+			"ready": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the Private Network has a cidr range assigned and all its instances report the status `ok`.",
+			},
+			"members_ready_count": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The number of instances of the Private Network which report the status `ok`, e.g. for a progress gauge along with the length of `instances`.",
+			},
 		},
 	}
 }
 
+// defaultPrivateNetworkRegion is the region of created Private Networks which
+// do not configure one.
+const defaultPrivateNetworkRegion = "EU"
+
+// privateNetworkImportAll is the import id importing all Private Networks.
+const privateNetworkImportAll = "all"
+
+// resourcePrivateNetworkImport accepts a single id, a comma separated list of
+// ids or "all". Every id beyond the first one is imported as an additional
+// resource of the same type.
+func resourcePrivateNetworkImport(
+	ctx context.Context,
+	d *schema.ResourceData,
+	m interface{},
+) ([]*schema.ResourceData, error) {
+	ids, err := parsePrivateNetworkImportIds(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 1 && ids[0] == privateNetworkImportAll {
+		privateNetworks, err := listPrivateNetworks(ctx, m.(*providerMeta).client, "", "")
+		if err != nil {
+			return nil, err
+		}
+		if len(privateNetworks) == 0 {
+			return nil, fmt.Errorf("there are no Private Networks to import")
+		}
+		ids = []string{}
+		for _, privateNetwork := range privateNetworks {
+			ids = append(ids, strconv.Itoa(int(privateNetwork.PrivateNetworkId)))
+		}
+	}
+
+	d.SetId(ids[0])
+	results := []*schema.ResourceData{d}
+	for _, id := range ids[1:] {
+		additional := resourcePrivateNetwork().Data(nil)
+		additional.SetId(id)
+		results = append(results, additional)
+	}
+	return results, nil
+}
+
+func parsePrivateNetworkImportIds(importId string) ([]string, error) {
+	if strings.TrimSpace(importId) == privateNetworkImportAll {
+		return []string{privateNetworkImportAll}, nil
+	}
+
+	ids := []string{}
+	for _, id := range strings.Split(importId, ",") {
+		id = strings.TrimSpace(id)
+		if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid Private Network id %q in import id %q, expected ids separated by commas or %q", id, importId, privateNetworkImportAll)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
 func resourcePrivateNetworkCreate(
 	ctx context.Context,
 	d *schema.ResourceData,
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	privateNetworkName := d.Get("name").(string)
-	privateNetworkDescription := d.Get("description").(string)
+	privateNetworkDescription := descriptionOrDefault(d, m)
 	privateNetworkRegion := d.Get("region").(string)
+	if privateNetworkRegion == "" {
+		privateNetworkRegion = defaultPrivateNetworkRegion
+	}
+
+	if !d.Get("allow_empty").(bool) && d.Get("instance_ids").(*schema.Set).Len() == 0 {
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Private Network without instances",
+			Detail:   fmt.Sprintf("instance_ids of the Private Network %q is empty. Add the instances or set allow_empty to true.", privateNetworkName),
+		})
+	}
+
+	adoptExisting := d.Get("adopt_existing").(bool)
+	if (adoptExisting || m.(*providerMeta).enforceUniqueNames) && privateNetworkName != "" {
+		existing, err := findPrivateNetworkByName(ctx, client, privateNetworkName)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if existing != nil && adoptExisting {
+			return adoptPrivateNetwork(ctx, d, m, *existing)
+		}
+		if existing != nil {
+			return append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Private Network name already taken",
+				Detail: fmt.Sprintf("The Private Network %d is named %q already and enforce_unique_names is set. Choose another name, import the Private Network or set adopt_existing.",
+					existing.PrivateNetworkId, privateNetworkName),
+			})
+		}
+	}
 
 	createPrivateNetworkRequest := openapi.NewCreatePrivateNetworkRequestWithDefaults()
 	createPrivateNetworkRequest.Name = privateNetworkName
 	createPrivateNetworkRequest.Description = &privateNetworkDescription
 	createPrivateNetworkRequest.Region = privateNetworkRegion
 
+	requestId := uuid.NewV4().String()
 	res, httpResp, err := client.PrivateNetworksApi.
-		CreatePrivateNetwork(context.Background()).
-		XRequestId(uuid.NewV4().String()).
+		CreatePrivateNetwork(ctx).
+		XRequestId(requestId).
 		CreatePrivateNetworkRequest(*createPrivateNetworkRequest).
 		Execute()
 
 	if err != nil {
 		return HandleResponseErrors(diags, httpResp)
 	}
+	d.Set("last_request_id", requestId)
 
 	if len(res.Data) != 1 {
 		return append(diags, diag.Diagnostic{
@@ -189,50 +432,261 @@ func resourcePrivateNetworkCreate(
 			Summary:  "Internal Error: should have returned only one object",
 		})
 	}
-	instancesToAdd := d.Get("instance_ids").(*schema.Set).List()
+	instancesToAdd := orderInstanceIds(d, d.Get("instance_ids").(*schema.Set).List())
 	privateNetworkId := res.Data[0].PrivateNetworkId
+	d.SetId(strconv.Itoa(int(privateNetworkId)))
 
-	for _, instanceId := range instancesToAdd {
-		instanceIdInt := instanceId.(int)
-		instanceId := int64(instanceIdInt)
+	// keep the network and the successfully added instances in the state
+	if rsltDiag := addInstancesToPrivateNetwork(ctx, diags, m.(*providerMeta), privateNetworkId, instancesToAdd); rsltDiag != nil {
+		return append(rsltDiag, resourcePrivateNetworkRead(ctx, d, m)...)
+	}
+	if rsltDiag := waitForPrivateIps(ctx, d, m.(*providerMeta), privateNetworkId, instancesToAdd); rsltDiag != nil {
+		return append(rsltDiag, resourcePrivateNetworkRead(ctx, d, m)...)
+	}
 
-		httpResp, err = retryAddPrivateNetworkAddOnToInstance(diags, client, instanceId, 0)
+	diags = append(diags, tagCreatedResource(ctx, d, client, privateNetworkTagResourceType)...)
+	return append(diags, resourcePrivateNetworkRead(ctx, d, m)...)
+}
 
-		if err != nil && !strings.Contains(err.Error(), httpConflict) {
-			return HandleResponseErrors(diags, httpResp)
+// findPrivateNetworkByName returns the Private Network with exactly the
+// given name, nil if there is none and an error if the name is ambiguous.
+func findPrivateNetworkByName(
+	ctx context.Context,
+	client *openapi.APIClient,
+	name string,
+) (*openapi.PrivateNetworkResponse, error) {
+	// the name filter of the API also matches partially
+	var matches []openapi.PrivateNetworkResponse
+	err := paginate(privateNetworkListPageSize, func(page int64) (int, int64, error) {
+		res, httpResp, err := client.PrivateNetworksApi.
+			RetrievePrivateNetworkList(ctx).
+			XRequestId(uuid.NewV4().String()).
+			Page(page).
+			Size(privateNetworkListPageSize).
+			Name(name).
+			Execute()
+		if err != nil {
+			return 0, 0, NewApiError(httpResp, err)
 		}
 
-		httpResp, err = assignInstanceToPrivateNetwork(diags, client, privateNetworkId, instanceId)
+		for _, privateNetwork := range res.Data {
+			if privateNetwork.Name == name {
+				matches = append(matches, privateNetwork)
+			}
+		}
+		return len(res.Data), int64(res.Pagination.TotalPages), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &matches[0], nil
+	default:
+		ids := make([]int64, 0, len(matches))
+		for _, privateNetwork := range matches {
+			ids = append(ids, privateNetwork.PrivateNetworkId)
+		}
+		return nil, fmt.Errorf("the Private Network name %q is ambiguous, it matches the Private Networks %v", name, ids)
+	}
+}
+
+// adoptPrivateNetwork takes over an existing Private Network instead of
+// creating a new one and applies the configured description and instances.
+func adoptPrivateNetwork(
+	ctx context.Context,
+	d *schema.ResourceData,
+	m interface{},
+	existing openapi.PrivateNetworkResponse,
+) diag.Diagnostics {
+	var diags diag.Diagnostics
+	client := m.(*providerMeta).client
+
+	privateNetworkId := existing.PrivateNetworkId
+	d.SetId(strconv.Itoa(int(privateNetworkId)))
+
+	// only the differences to the configuration are applied, adopting a
+	// matching Private Network does not change it at all
+	description := descriptionOrDefault(d, m)
+	if description != existing.Description {
+		updatePrivateNetworkRequest := openapi.NewPatchPrivateNetworkRequest()
+		updatePrivateNetworkRequest.Description = &description
+
+		requestId := uuid.NewV4().String()
+		_, httpResp, err := client.PrivateNetworksApi.
+			PatchPrivateNetwork(ctx, privateNetworkId).
+			XRequestId(requestId).
+			PatchPrivateNetworkRequest(*updatePrivateNetworkRequest).
+			Execute()
+
 		if err != nil {
 			return HandleResponseErrors(diags, httpResp)
 		}
+		d.Set("last_request_id", requestId)
 	}
-	d.SetId(strconv.Itoa(int(privateNetworkId)))
-	return resourcePrivateNetworkRead(ctx, d, m)
+
+	// instances which already are members must not be assigned again
+	instancesToAdd := d.Get("instance_ids").(*schema.Set)
+	for _, instance := range existing.Instances {
+		instancesToAdd.Remove(int(instance.InstanceId))
+	}
+	if rsltDiag := addInstancesToPrivateNetwork(ctx, diags, m.(*providerMeta), privateNetworkId, orderInstanceIds(d, instancesToAdd.List())); rsltDiag != nil {
+		return append(rsltDiag, resourcePrivateNetworkRead(ctx, d, m)...)
+	}
+	if rsltDiag := waitForPrivateIps(ctx, d, m.(*providerMeta), privateNetworkId, instancesToAdd.List()); rsltDiag != nil {
+		return append(rsltDiag, resourcePrivateNetworkRead(ctx, d, m)...)
+	}
+
+	diags = append(diags, tagAdoptedResource(ctx, d, client, privateNetworkTagResourceType)...)
+	return append(diags, resourcePrivateNetworkRead(ctx, d, m)...)
+}
+
+// addInstancesToPrivateNetwork ensures the private networking add-on on every
+// instance and assigns it to the Private Network. A failing instance does not
+// stop the others, all failed instances are listed in a single error.
+func addInstancesToPrivateNetwork(
+	ctx context.Context,
+	diags diag.Diagnostics,
+	meta *providerMeta,
+	privateNetworkId int64,
+	instanceIds []interface{},
+) diag.Diagnostics {
+	client := meta.client
+	failed := false
+	for _, instanceId := range instanceIds {
+		instanceIdInt := instanceId.(int)
+		instanceId := int64(instanceIdInt)
+
+		if err := addInstanceToPrivateNetwork(ctx, diags, meta, privateNetworkId, instanceId); err != nil {
+			failed = true
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("Could not add instance %d to the Private Network %d", instanceId, privateNetworkId),
+				Detail:   describeInstanceFailure(ctx, client, instanceId, err),
+			})
+		}
+	}
+
+	if failed {
+		return diags
+	}
+	return nil
+}
+
+// describeInstanceFailure explains why the instance could not be added, along
+// with its current status and error message, e.g. if it is still installing.
+// The error of the API calls includes their request ids.
+func describeInstanceFailure(ctx context.Context, client *openapi.APIClient, instanceId int64, err error) string {
+	detail := fmt.Sprintf("instance %d: %s", instanceId, err)
+
+	res, _, lookupErr := client.InstancesApi.
+		RetrieveInstance(ctx, instanceId).
+		XRequestId(uuid.NewV4().String()).
+		Execute()
+	if lookupErr != nil || len(res.Data) != 1 {
+		return detail
+	}
+
+	instance := res.Data[0]
+	detail += fmt.Sprintf("\nThe instance has the status %q", instance.Status)
+	if instance.ErrorMessage != nil && *instance.ErrorMessage != "" {
+		detail += fmt.Sprintf(" and the error message %q", *instance.ErrorMessage)
+	}
+	return detail + "."
+}
+
+// addInstanceToPrivateNetwork adds the private networking add-on to a single
+// instance and assigns it. The add-on upgrade has its own retry budget of
+// maxRetries per instance.
+func addInstanceToPrivateNetwork(
+	ctx context.Context,
+	diags diag.Diagnostics,
+	meta *providerMeta,
+	privateNetworkId int64,
+	instanceId int64,
+) error {
+	client, maxRetries := meta.client, meta.maxRetries
+	httpResp, err := retryAddPrivateNetworkAddOnToInstance(ctx, diags, client, instanceId, maxRetries)
+
+	// a conflict means the instance already has the private networking
+	// add-on, unless the provider is configured to treat it as an error
+	if err != nil {
+		if apiError := NewApiError(httpResp, err); !apiError.IsConflict() || meta.treatConflictAsError {
+			return apiError
+		}
+	}
+
+	// the instance may have been assigned externally in the meantime, which
+	// would let the assignment fail with a conflict
+	assigned, httpResp, err := isInstanceInPrivateNetwork(ctx, client, privateNetworkId, instanceId)
+	if err != nil {
+		return NewApiError(httpResp, err)
+	}
+	if assigned {
+		return nil
+	}
+
+	httpResp, err = retryAssignInstanceToPrivateNetwork(ctx, diags, meta, privateNetworkId, instanceId)
+	if err != nil {
+		return NewApiError(httpResp, err)
+	}
+	return nil
+}
+
+// isInstanceInPrivateNetwork reads the current members of the Private Network
+// and reports whether the instance is one of them.
+func isInstanceInPrivateNetwork(
+	ctx context.Context,
+	client *openapi.APIClient,
+	privateNetworkId int64,
+	instanceId int64,
+) (bool, *http.Response, error) {
+	res, httpResp, err := client.PrivateNetworksApi.
+		RetrievePrivateNetwork(ctx, privateNetworkId).
+		XRequestId(uuid.NewV4().String()).
+		Execute()
+	if err != nil {
+		return false, httpResp, err
+	}
+
+	for _, privateNetwork := range res.Data {
+		for _, instance := range privateNetwork.Instances {
+			if instance.InstanceId == instanceId {
+				return true, httpResp, nil
+			}
+		}
+	}
+	return false, httpResp, nil
 }
 
 func assignInstanceToPrivateNetwork(
+	ctx context.Context,
 	diags diag.Diagnostics,
 	client *openapi.APIClient,
 	privateNetworkId,
-	instanceId int64) (*http.Response, error) {
+	instanceId int64,
+	requestId string) (*http.Response, error) {
 
 	_, httpResp, err := client.PrivateNetworksApi.AssignInstancePrivateNetwork(
-		context.Background(),
+		ctx,
 		privateNetworkId,
-		instanceId).XRequestId(uuid.NewV4().String()).Execute()
+		instanceId).XRequestId(requestId).Execute()
 
 	return httpResp, err
 }
 
 func unassignInstanceToPrivateNetwork(
+	ctx context.Context,
 	diags diag.Diagnostics,
 	client *openapi.APIClient,
 	privateNetworkId int64,
 	instanceId int64) (*http.Response, error) {
 
 	_, httpResp, err := client.PrivateNetworksApi.UnassignInstancePrivateNetwork(
-		context.Background(),
+		ctx,
 		privateNetworkId,
 		instanceId).XRequestId(uuid.NewV4().String()).Execute()
 
@@ -240,29 +694,164 @@ func unassignInstanceToPrivateNetwork(
 }
 
 func addPrivateNetworkAddOnToInstance(
+	ctx context.Context,
 	diags diag.Diagnostics,
 	client *openapi.APIClient,
 	instanceId int64) (*http.Response, error) {
 
-	var upgradeInstance openapi.UpgradeInstanceRequest
-	// we are sending an empty object for now
-	//according to the upgradeInstanceRequest openapi spec
-	privateNetworking := make(map[string]interface{})
-	upgradeInstance.PrivateNetworking = &privateNetworking
+	upgradeInstance, err := privateNetworkingAddOnRequest(privateNetworkingAddOnSpecVersion)
+	if err != nil {
+		return nil, err
+	}
 
-	_, httpResp, err := client.InstancesApi.UpgradeInstance(context.Background(), instanceId).XRequestId(uuid.NewV4().String()).
+	_, httpResp, err := client.InstancesApi.UpgradeInstance(ctx, instanceId).XRequestId(uuid.NewV4().String()).
 		UpgradeInstanceRequest(upgradeInstance).
 		Execute()
 	return httpResp, err
 }
 
+// privateNetworkingAddOnSpecVersion is the version of the UpgradeInstance
+// spec the private networking add-on payload is built for. Bump it along with
+// a new case in privateNetworkingAddOnRequest if the spec adds fields to the
+// add-on.
+const privateNetworkingAddOnSpecVersion = 1
+
+// privateNetworkingAddOnRequest builds the upgrade request ordering the
+// private networking add-on for the given spec version.
+func privateNetworkingAddOnRequest(specVersion int) (openapi.UpgradeInstanceRequest, error) {
+	var upgradeInstance openapi.UpgradeInstanceRequest
+
+	switch specVersion {
+	case 1:
+		// the add-on is ordered with an empty object
+		privateNetworking := make(map[string]interface{})
+		upgradeInstance.PrivateNetworking = &privateNetworking
+	default:
+		return upgradeInstance, fmt.Errorf("unsupported UpgradeInstance spec version %d for the private networking add-on", specVersion)
+	}
+	return upgradeInstance, nil
+}
+
+// resourcePrivateNetworkRefresh reads the Private Network and warns about
+// instances which were added or removed outside of Terraform since the last
+// apply. The state is updated nevertheless.
+func resourcePrivateNetworkRefresh(
+	ctx context.Context,
+	d *schema.ResourceData,
+	m interface{},
+) diag.Diagnostics {
+	// nothing was applied yet while importing
+	imported := d.Get("name").(string) == ""
+	checkAddOns := d.Get("check_addons").(bool) && !d.Get("ignore_instances").(bool)
+	previousInstanceIds := d.Get("instance_ids").(*schema.Set)
+	if checkAddOns {
+		// instances dropped by the last repair are still members
+		previousInstanceIds = previousInstanceIds.Union(repairedInstanceIds(d))
+	}
+
+	diags := resourcePrivateNetworkRead(ctx, d, m)
+	if diags.HasError() || d.Id() == "" {
+		return diags
+	}
+
+	if !imported {
+		diags = append(diags, warnAboutExternalInstanceChanges(d, previousInstanceIds)...)
+	}
+	if checkAddOns {
+		diags = append(diags, repairMissingAddOns(d)...)
+	}
+	return diags
+}
+
+// repairedInstanceIds returns the member instances which repairMissingAddOns
+// dropped from instance_ids and which were not applied since.
+func repairedInstanceIds(d *schema.ResourceData) *schema.Set {
+	instanceIds := d.Get("instance_ids").(*schema.Set)
+	repaired := schema.NewSet(instanceIds.F, nil)
+	for _, instance := range d.Get("instances").([]interface{}) {
+		instance := instance.(map[string]interface{})
+		if instance["region"].(string) != "" &&
+			!instance["has_private_networking_addon"].(bool) &&
+			!instanceIds.Contains(instance["instance_id"].(int)) {
+			repaired.Add(instance["instance_id"].(int))
+		}
+	}
+	return repaired
+}
+
+func warnAboutExternalInstanceChanges(d *schema.ResourceData, previousInstanceIds *schema.Set) diag.Diagnostics {
+	currentInstanceIds := d.Get("instance_ids").(*schema.Set)
+	added := currentInstanceIds.Difference(previousInstanceIds)
+	removed := previousInstanceIds.Difference(currentInstanceIds)
+	if added.Len() == 0 && removed.Len() == 0 {
+		return nil
+	}
+
+	changes := []string{}
+	if added.Len() > 0 {
+		changes = append(changes, fmt.Sprintf("the instances %s were added", formatInstanceIds(added)))
+	}
+	if removed.Len() > 0 {
+		changes = append(changes, fmt.Sprintf("the instances %s were removed", formatInstanceIds(removed)))
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  "Instances of the Private Network changed outside of Terraform",
+		Detail: fmt.Sprintf("Since the last apply %s in the Private Network %s.",
+			strings.Join(changes, " and "), d.Id()),
+	}}
+}
+
+// repairMissingAddOns warns about member instances whose private networking
+// add-on is gone and drops them from instance_ids, so that the next apply adds
+// them again, which orders the add-on. Instances whose details could not be
+// read are left alone.
+func repairMissingAddOns(d *schema.ResourceData) diag.Diagnostics {
+	instanceIds := d.Get("instance_ids").(*schema.Set)
+	missing := schema.NewSet(schema.HashInt, nil)
+	for _, instance := range d.Get("instances").([]interface{}) {
+		instance := instance.(map[string]interface{})
+		if instance["region"].(string) == "" {
+			continue
+		}
+		if !instance["has_private_networking_addon"].(bool) {
+			missing.Add(instance["instance_id"].(int))
+			instanceIds.Remove(instance["instance_id"].(int))
+		}
+	}
+	if missing.Len() == 0 {
+		return nil
+	}
+
+	if err := d.Set("instance_ids", instanceIds); err != nil {
+		return diag.FromErr(err)
+	}
+	return diag.Diagnostics{{
+		Severity: diag.Warning,
+		Summary:  "Instances of the Private Network without private networking add-on",
+		Detail: fmt.Sprintf(
+			"The instances %s are in the Private Network %s, but their private networking add-on was removed outside of Terraform. Apply again to add it.",
+			formatInstanceIds(missing), d.Id()),
+	}}
+}
+
+// formatInstanceIds lists the sorted instance ids of the set, e.g. [10 20].
+func formatInstanceIds(instanceIds *schema.Set) string {
+	ids := []int{}
+	for _, instanceId := range instanceIds.List() {
+		ids = append(ids, instanceId.(int))
+	}
+	sort.Ints(ids)
+	return fmt.Sprint(ids)
+}
+
 func resourcePrivateNetworkRead(
 	ctx context.Context,
 	d *schema.ResourceData,
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	privateNetworkId, err := strconv.ParseInt(d.Id(), 10, 64)
 
@@ -270,6 +859,9 @@ func resourcePrivateNetworkRead(
 		return diag.FromErr(err)
 	}
 
+	configuredInstanceIds := d.Get("instance_ids")
+
+	start := time.Now()
 	res, httpResp, err := client.PrivateNetworksApi.
 		RetrievePrivateNetwork(ctx, privateNetworkId).
 		XRequestId(uuid.NewV4().String()).
@@ -286,7 +878,62 @@ func resourcePrivateNetworkRead(
 		})
 	}
 
-	return AddPrivateNetworkToData(res.Data[0], d, diags)
+	return addPrivateNetworkResponseToData(ctx, d, m, res.Data[0], httpResp, start, configuredInstanceIds)
+}
+
+// addPrivateNetworkResponseToData stores the Private Network returned by a
+// call started at start in the state, along with the details of its
+// instances. configuredInstanceIds are kept if ignore_instances is set.
+func addPrivateNetworkResponseToData(
+	ctx context.Context,
+	d *schema.ResourceData,
+	m interface{},
+	privateNetwork openapi.PrivateNetworkResponse,
+	httpResp *http.Response,
+	start time.Time,
+	configuredInstanceIds interface{},
+) diag.Diagnostics {
+	var diags diag.Diagnostics
+	client := m.(*providerMeta).client
+
+	diags = AddPrivateNetworkToData(privateNetwork, d, diags)
+	diags = AddDebugLastResponseToData(m, httpResp, d, diags)
+	diags = AddDebugReadDurationToData(m, start, d, diags)
+	if diags.HasError() {
+		return diags
+	}
+
+	// the membership is managed outside of Terraform, keep the configured one
+	if d.Get("ignore_instances").(bool) {
+		if err := d.Set("instance_ids", configuredInstanceIds); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.Get("include_instance_details").(bool) || d.Get("check_addons").(bool) {
+		diags = AddInstanceDetailsToData(ctx, client, d, diags)
+		if diags.HasError() {
+			return diags
+		}
+	}
+
+	return AddDataCenterS3UrlToData(ctx, client, privateNetwork.DataCenter, d, diags)
+}
+
+// isCompletePatchResponse reports whether the PATCH returned the whole
+// updated Private Network, so that it need not be read again. A response
+// without creation date or without the instances the state expects is taken
+// as incomplete.
+func isCompletePatchResponse(res openapi.PatchPrivateNetworkResponse, privateNetworkId int64, d *schema.ResourceData) bool {
+	if len(res.Data) != 1 {
+		return false
+	}
+
+	privateNetwork := res.Data[0]
+	if privateNetwork.PrivateNetworkId != privateNetworkId || privateNetwork.CreatedDate.IsZero() {
+		return false
+	}
+	return len(privateNetwork.Instances) > 0 || d.Get("instance_ids").(*schema.Set).Len() == 0
 }
 
 func resourcePrivateNetworkUpdate(
@@ -295,7 +942,7 @@ func resourcePrivateNetworkUpdate(
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	privateNetworkId, err := strconv.ParseInt(d.Id(), 10, 64)
 
@@ -318,83 +965,308 @@ func resourcePrivateNetworkUpdate(
 		anyChange = true
 	}
 
-	if d.HasChange("instance_ids") {
-		rsltDiag := handleInstanceChanges(diags, d, client, privateNetworkId)
+	// The API has no atomic update, the instance changes are applied first,
+	// then the tags and finally the name and description. If a step fails, the
+	// Private Network is read again, so that the state keeps the steps which
+	// succeeded and the next apply only repeats the remaining ones.
+	if d.HasChange("instance_ids") && d.Get("ignore_instances").(bool) {
+		// the membership is managed outside of Terraform, the configured
+		// instance_ids are kept in the state without applying them
+	} else if d.HasChange("instance_ids") && d.Get("assign_on_create_only").(bool) {
+		// keep the actual instances in the state instead of the ignored ones
+		old, _ := d.GetChange("instance_ids")
+		if err := d.Set("instance_ids", old); err != nil {
+			return diag.FromErr(err)
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Instance changes of the Private Network ignored",
+			Detail:   fmt.Sprintf("assign_on_create_only is set, so the changed instance_ids of the Private Network %s are not applied. Assign or unassign the instances in the Customer Control Panel or unset assign_on_create_only.", d.Id()),
+		})
+	} else if d.HasChange("instance_ids") {
+		rsltDiag := handleInstanceChanges(ctx, diags, d, m.(*providerMeta), privateNetworkId)
 		if rsltDiag != nil {
-			return rsltDiag
+			return append(rsltDiag, resourcePrivateNetworkRead(ctx, d, m)...)
 		}
 		anyChange = true
 	}
 
+	if d.HasChange("tags") {
+		if rsltDiag := updateTags(ctx, d, client, privateNetworkTagResourceType); rsltDiag.HasError() {
+			return append(rsltDiag, resourcePrivateNetworkRead(ctx, d, m)...)
+		}
+	}
+
 	if anyChange {
-		_, httpResp, err := client.PrivateNetworksApi.
-			PatchPrivateNetwork(context.Background(), privateNetworkId).
-			XRequestId(uuid.NewV4().String()).
+		requestId := uuid.NewV4().String()
+		configuredInstanceIds := d.Get("instance_ids")
+		start := time.Now()
+		res, httpResp, err := client.PrivateNetworksApi.
+			PatchPrivateNetwork(ctx, privateNetworkId).
+			XRequestId(requestId).
 			PatchPrivateNetworkRequest(*updatePrivateNetworkRequest).
 			Execute()
 
 		if err != nil {
-			return HandleResponseErrors(diags, httpResp)
+			return append(HandleResponseErrors(diags, httpResp), resourcePrivateNetworkRead(ctx, d, m)...)
 		}
+		d.Set("last_request_id", requestId)
+
+		updatedAt := time.Now()
+		d.Set("updated_at", updatedAt.Format(time.RFC850))
+		d.Set("updated_at_rfc3339", updatedAt.Format(time.RFC3339))
 
-		d.Set("updated_at", time.Now().Format(time.RFC850))
-		return resourcePrivateNetworkRead(ctx, d, m)
+		// the PATCH usually returns the updated Private Network, which saves
+		// reading it again
+		if isCompletePatchResponse(res, privateNetworkId, d) {
+			return append(diags, addPrivateNetworkResponseToData(ctx, d, m, res.Data[0], httpResp, start, configuredInstanceIds)...)
+		}
+		return append(diags, resourcePrivateNetworkRead(ctx, d, m)...)
 	}
 	return diags
 }
 
-func handleInstanceChanges(diags diag.Diagnostics,
+func handleInstanceChanges(ctx context.Context,
+	diags diag.Diagnostics,
 	d *schema.ResourceData,
-	client *openapi.APIClient,
+	meta *providerMeta,
 	privateNetworkId int64) diag.Diagnostics {
 
-	//Remove instances which are not more in this private network
+	// instances staying in the private network are left untouched, so that
+	// they do not lose their connectivity during the apply. A replaced
+	// instance shows up as its old id being removed and its new id being
+	// added, the old id is unassigned first. As the old instance is usually
+	// gone already, its unassign counts as done with a 404.
 	old, new := d.GetChange("instance_ids")
-	oldInstanceIds := old.(*schema.Set).List()
-	for _, instanceId := range oldInstanceIds {
-		instanceIdInt := instanceId.(int)
-		instanceId := int64(instanceIdInt)
 
-		httpResp, err := unassignInstanceToPrivateNetwork(diags, client, privateNetworkId, instanceId)
-		if err != nil {
-			return HandleResponseErrors(diags, httpResp)
-		}
+	//Remove instances which are not more in this private network
+	removedInstanceIds := old.(*schema.Set).Difference(new.(*schema.Set)).List()
+	if rsltDiag := unassignInstancesFromPrivateNetwork(ctx, diags, meta.client, privateNetworkId, removedInstanceIds); rsltDiag.HasError() {
+		return rsltDiag
 	}
 
 	//Add new instances which are now in this private network
-	newInstanceIds := new.(*schema.Set).List()
-	for _, instanceId := range newInstanceIds {
-		instanceIdInt := instanceId.(int)
-		instanceId := int64(instanceIdInt)
+	addedInstanceIds := orderInstanceIds(d, new.(*schema.Set).Difference(old.(*schema.Set)).List())
+	if rsltDiag := addInstancesToPrivateNetwork(ctx, diags, meta, privateNetworkId, addedInstanceIds); rsltDiag != nil {
+		return rsltDiag
+	}
+	return waitForPrivateIps(ctx, d, meta, privateNetworkId, addedInstanceIds)
+}
 
-		httpResp, err := retryAddPrivateNetworkAddOnToInstance(diags, client, instanceId, 0)
+// waitForPrivateIps waits until each of the assigned instances has a private
+// IPv4 address in the Private Network, if wait_for_private_ip is set. Like
+// addInstancesToPrivateNetwork, it returns nil if there is nothing to report.
+func waitForPrivateIps(
+	ctx context.Context,
+	d *schema.ResourceData,
+	meta *providerMeta,
+	privateNetworkId int64,
+	instanceIds []interface{},
+) diag.Diagnostics {
+	if !d.Get("wait_for_private_ip").(bool) || len(instanceIds) == 0 {
+		return nil
+	}
 
-		if err != nil && !strings.Contains(err.Error(), httpConflict) {
-			return HandleResponseErrors(diags, httpResp)
-		}
+	timeout := meta.pollTimeout
+	if timeout <= 0 {
+		timeout = defaultPollTimeout
+	}
+	var missing []int64
+	stateChangeConf := &resource.StateChangeConf{
+		Pending:      []string{"WAITING"},
+		Target:       []string{"READY"},
+		Timeout:      timeout,
+		PollInterval: meta.pollInterval,
+		Refresh: func() (interface{}, string, error) {
+			res, httpResp, err := meta.client.PrivateNetworksApi.
+				RetrievePrivateNetwork(ctx, privateNetworkId).
+				XRequestId(uuid.NewV4().String()).
+				Execute()
+			if err != nil {
+				return nil, "", NewApiError(httpResp, err)
+			}
+			if len(res.Data) != 1 {
+				return nil, "", fmt.Errorf("Internal Error: should have returned only one object")
+			}
+
+			withIp := map[int64]bool{}
+			for _, instance := range res.Data[0].Instances {
+				withIp[instance.InstanceId] = len(instance.PrivateIpConfig.V4) > 0
+			}
+			missing = nil
+			for _, instanceId := range instanceIds {
+				if !withIp[int64(instanceId.(int))] {
+					missing = append(missing, int64(instanceId.(int)))
+				}
+			}
+			if len(missing) > 0 {
+				return res.Data[0], "WAITING", nil
+			}
+			return res.Data[0], "READY", nil
+		},
+	}
 
-		httpResp, err = assignInstanceToPrivateNetwork(diags, client, privateNetworkId, instanceId)
-		if err != nil {
-			return HandleResponseErrors(diags, httpResp)
-		}
+	if _, err := stateChangeConf.WaitForStateContext(ctx); err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Private IP addresses missing",
+			Detail:   fmt.Sprintf("The instances %v of the Private Network %d have no private IPv4 address yet: %s", missing, privateNetworkId, err),
+		}}
 	}
 	return nil
 }
 
+// orderInstanceIds moves the instances listed in instance_order to the front,
+// in that order, and keeps the order of the others.
+func orderInstanceIds(d *schema.ResourceData, instanceIds []interface{}) []interface{} {
+	remaining := map[int]bool{}
+	for _, instanceId := range instanceIds {
+		remaining[instanceId.(int)] = true
+	}
+
+	ordered := make([]interface{}, 0, len(instanceIds))
+	for _, instanceId := range d.Get("instance_order").([]interface{}) {
+		if remaining[instanceId.(int)] {
+			ordered = append(ordered, instanceId)
+			delete(remaining, instanceId.(int))
+		}
+	}
+	for _, instanceId := range instanceIds {
+		if remaining[instanceId.(int)] {
+			ordered = append(ordered, instanceId)
+		}
+	}
+	return ordered
+}
+
+// maxConcurrentUnassigns bounds the parallel unassign calls, the API offers
+// no bulk unassign.
+const maxConcurrentUnassigns = 4
+
+// unassignInstancesFromPrivateNetwork unassigns the instances in parallel and
+// aggregates the errors of all calls. Instances which are not assigned anymore
+// (404) count as unassigned.
+func unassignInstancesFromPrivateNetwork(
+	ctx context.Context,
+	diags diag.Diagnostics,
+	client *openapi.APIClient,
+	privateNetworkId int64,
+	instanceIds []interface{},
+) diag.Diagnostics {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	semaphore := make(chan struct{}, maxConcurrentUnassigns)
+
+	for _, instanceId := range instanceIds {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(instanceId int64) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			httpResp, err := unassignInstanceToPrivateNetwork(ctx, nil, client, privateNetworkId, instanceId)
+			if err == nil {
+				return
+			}
+
+			apiError, errDiags := HandleApiError(nil, httpResp, err)
+			if apiError.IsNotFound() {
+				return
+			}
+			mutex.Lock()
+			diags = append(diags, errDiags...)
+			mutex.Unlock()
+		}(int64(instanceId.(int)))
+	}
+	wg.Wait()
+
+	return diags
+}
+
 func retryAddPrivateNetworkAddOnToInstance(
+	ctx context.Context,
 	diags diag.Diagnostics,
 	client *openapi.APIClient,
 	instanceId int64,
-	depht int8,
+	maxRetries int,
 ) (*http.Response, error) {
-	httpResp, err := addPrivateNetworkAddOnToInstance(diags, client, instanceId)
+	// retrying a conflict is pointless, the add-on is already there
+	retryable := func(apiError *ApiError) bool {
+		return !apiError.IsConflict()
+	}
+
+	return retryWhile(ctx, maxRetries, retryable, func(ctx context.Context, attempt int) (*http.Response, error) {
+		return addPrivateNetworkAddOnToInstance(ctx, diags, client, instanceId)
+	})
+}
 
-	if err != nil && depht < 10 {
-		time.Sleep(time.Second)
-		return retryAddPrivateNetworkAddOnToInstance(diags, client, instanceId, depht+1)
+// retryAssignInstanceToPrivateNetwork retries the assignment while it fails
+// transiently or with a conflict. Unlike for the add-on, a conflict is
+// transient here, it is returned while an operation of the instance, e.g. a
+// reboot, is in progress. Every attempt is logged with its request id.
+func retryAssignInstanceToPrivateNetwork(
+	ctx context.Context,
+	diags diag.Diagnostics,
+	meta *providerMeta,
+	privateNetworkId int64,
+	instanceId int64,
+) (*http.Response, error) {
+	client := meta.client
+	retryable := func(apiError *ApiError) bool {
+		return meta.isTransient(apiError) || apiError.IsConflict()
 	}
 
-	return httpResp, err
+	return retryWhile(ctx, meta.maxRetries, retryable, func(ctx context.Context, attempt int) (*http.Response, error) {
+		requestId := uuid.NewV4().String()
+		fields := map[string]interface{}{
+			"instance_id":        instanceId,
+			"private_network_id": privateNetworkId,
+			"attempt":            attempt,
+			"request_id":         requestId,
+		}
+		tflog.Debug(ctx, "assigning instance to Private Network", fields)
+
+		httpResp, err := assignInstanceToPrivateNetwork(ctx, diags, client, privateNetworkId, instanceId, requestId)
+		if err != nil {
+			fields["error"] = NewApiError(httpResp, err).Error()
+			tflog.Debug(ctx, "could not assign instance to Private Network", fields)
+		}
+		return httpResp, err
+	})
+}
+
+// waitForPrivateNetworkDeletion polls the Private Network every poll_interval
+// until the API does not know it anymore, at most for the timeout.
+func waitForPrivateNetworkDeletion(
+	ctx context.Context,
+	diags diag.Diagnostics,
+	meta *providerMeta,
+	privateNetworkId int64,
+	timeout time.Duration,
+) diag.Diagnostics {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		_, httpResp, err := meta.client.PrivateNetworksApi.
+			RetrievePrivateNetwork(ctx, privateNetworkId).
+			XRequestId(uuid.NewV4().String()).
+			Execute()
+
+		if err != nil && ctx.Err() == nil {
+			apiError, errDiags := HandleApiError(diags, httpResp, err)
+			if apiError.IsNotFound() {
+				return diags
+			}
+			return errDiags
+		}
+
+		select {
+		case <-ctx.Done():
+			return diag.FromErr(fmt.Errorf("the Private Network %d is not gone after %s: %w", privateNetworkId, timeout, ctx.Err()))
+		case <-time.After(meta.pollInterval):
+		}
+	}
 }
 
 func resourcePrivateNetworkDelete(
@@ -403,7 +1275,15 @@ func resourcePrivateNetworkDelete(
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
+
+	if d.Get("deletion_protection").(bool) {
+		return append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Private Network is protected against deletion",
+			Detail:   fmt.Sprintf("Set deletion_protection to false and apply before deleting the Private Network %s.", d.Id()),
+		})
+	}
 
 	privateNetworkId, err := strconv.ParseInt(d.Id(), 10, 64)
 
@@ -411,17 +1291,34 @@ func resourcePrivateNetworkDelete(
 		return diag.FromErr(err)
 	}
 
-	readRes, httpResp, err := client.PrivateNetworksApi.
-		RetrievePrivateNetwork(ctx, privateNetworkId).
-		XRequestId(uuid.NewV4().String()).
-		Execute()
+	var readRes openapi.FindPrivateNetworkResponse
+	httpResp, err := retryOnTransientError(ctx, m.(*providerMeta), func(ctx context.Context) (*http.Response, error) {
+		var httpResp *http.Response
+		var err error
+		readRes, httpResp, err = client.PrivateNetworksApi.
+			RetrievePrivateNetwork(ctx, privateNetworkId).
+			XRequestId(uuid.NewV4().String()).
+			Execute()
+		return httpResp, err
+	})
 
 	if err != nil {
-		return HandleResponseErrors(diags, httpResp)
+		apiError, errDiags := HandleApiError(diags, httpResp, err)
+		if apiError.IsNotFound() {
+			// already gone, nothing left to delete
+			d.SetId("")
+			return diags
+		}
+		return errDiags
 	}
 
-	for _, i := range readRes.Data[0].Instances {
-		client.PrivateNetworksApi.UnassignInstancePrivateNetwork(ctx, privateNetworkId, i.InstanceId).XRequestId(uuid.NewV4().String()).Execute()
+	// the Private Network can only be deleted once all instances are unassigned
+	instanceIds := []interface{}{}
+	for _, instance := range readRes.Data[0].Instances {
+		instanceIds = append(instanceIds, int(instance.InstanceId))
+	}
+	if rsltDiag := unassignInstancesFromPrivateNetwork(ctx, diags, client, privateNetworkId, instanceIds); rsltDiag.HasError() {
+		return rsltDiag
 	}
 
 	httpResp, err = client.PrivateNetworksApi.
@@ -433,6 +1330,13 @@ func resourcePrivateNetworkDelete(
 		return HandleResponseErrors(diags, httpResp)
 	}
 
+	if d.Get("wait_for_deletion").(bool) {
+		timeout := waitTimeout(d, m, schema.TimeoutDelete, defaultPollTimeout)
+		if rsltDiag := waitForPrivateNetworkDeletion(ctx, diags, m.(*providerMeta), privateNetworkId, timeout); rsltDiag.HasError() {
+			return rsltDiag
+		}
+	}
+
 	d.SetId("")
 
 	return diags
@@ -447,6 +1351,9 @@ func AddPrivateNetworkToData(
 	if err := d.Set("id", id); err != nil {
 		return diag.FromErr(err)
 	}
+	if err := d.Set("private_network_id", int(privateNetwork.PrivateNetworkId)); err != nil {
+		return diag.FromErr(err)
+	}
 	if err := d.Set("name", privateNetwork.Name); err != nil {
 		return diag.FromErr(err)
 	}
@@ -469,11 +1376,26 @@ func AddPrivateNetworkToData(
 	if err := d.Set("created_date", createdDate); err != nil {
 		return diag.FromErr(err)
 	}
+	if err := d.Set("created_date_rfc3339", privateNetwork.CreatedDate.Format(time.RFC3339)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("created_by", privateNetwork.CustomerId); err != nil {
+		return diag.FromErr(err)
+	}
 
+	// never nil, so that a network without instances has empty lists in the
+	// state instead of unknown values, e.g. for consumers using for_each
 	instanceIds := []int64{}
 	instances := []map[string]interface{}{}
 
-	for _, instance := range privateNetwork.Instances {
+	// the API does not guarantee an order, sort to avoid diffs on refresh
+	sortedInstances := make([]openapi.Instances, len(privateNetwork.Instances))
+	copy(sortedInstances, privateNetwork.Instances)
+	sort.Slice(sortedInstances, func(i, j int) bool {
+		return sortedInstances[i].InstanceId < sortedInstances[j].InstanceId
+	})
+
+	for _, instance := range sortedInstances {
 		instanceIds = append(instanceIds, instance.InstanceId)
 		instances = append(instances, buildInstanceIpConfig(instance))
 	}
@@ -485,9 +1407,232 @@ func AddPrivateNetworkToData(
 		return diag.FromErr(err)
 	}
 
+	if err := d.Set("ready", isPrivateNetworkReady(privateNetwork)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("members_ready_count", countReadyInstances(privateNetwork)); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("network_gateway", networkGateway(privateNetwork.Cidr)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	firstIp, lastIp, hostCount := cidrHostRange(privateNetwork.Cidr)
+	if err := d.Set("cidr_first_ip", firstIp); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("cidr_last_ip", lastIp); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("cidr_host_count", hostCount); err != nil {
+		return diag.FromErr(err)
+	}
+
 	return diags
 }
 
+// validatePrivateNetworkCapacity rejects more instance_ids than the cidr range
+// of the Private Network has addresses for at plan time. The gateway takes one
+// of the usable addresses. The cidr range is only known once the Private
+// Network is created, so the check applies to updates.
+func validatePrivateNetworkCapacity(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	cidr := d.Get("cidr").(string)
+	if !d.NewValueKnown("cidr") || !d.NewValueKnown("instance_ids") || cidr == "" || d.Get("ignore_instances").(bool) {
+		return nil
+	}
+
+	_, _, hostCount := cidrHostRange(cidr)
+	capacity := hostCount - 1
+	if capacity < 0 {
+		capacity = 0
+	}
+	// the diff reads elements removed from the set as 0, no instance has it
+	instanceCount := 0
+	for _, instanceId := range d.Get("instance_ids").(*schema.Set).List() {
+		if instanceId.(int) != 0 {
+			instanceCount++
+		}
+	}
+	if instanceCount > capacity {
+		return fmt.Errorf(
+			"the Private Network %s with the cidr range %s has addresses for %d instances besides its gateway, but instance_ids contains %d instances",
+			d.Id(), cidr, capacity, instanceCount)
+	}
+	return nil
+}
+
+// networkGateway returns the first usable address of the cidr range as
+// reported by cidrHostRange, or an empty string if the cidr range is not (yet)
+// valid.
+func networkGateway(cidr string) string {
+	gateway, _, _ := cidrHostRange(cidr)
+	return gateway
+}
+
+// cidrHostRange returns the first and last usable host address of the IPv4
+// cidr range along with the number of usable addresses. The network and
+// broadcast addresses are not usable, except for /31 point-to-point ranges
+// (RFC 3021) and /32 single host ranges. An invalid cidr range has no hosts.
+func cidrHostRange(cidr string) (string, string, int) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil || network.IP.To4() == nil {
+		return "", "", 0
+	}
+
+	ones, bits := network.Mask.Size()
+	first := binary.BigEndian.Uint32(network.IP.To4())
+	last := first | uint32(1<<uint(bits-ones)-1)
+	if ones < 31 {
+		first++
+		last--
+	}
+
+	return uint32ToIp(first).String(), uint32ToIp(last).String(), int(last-first) + 1
+}
+
+func uint32ToIp(address uint32) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, address)
+	return ip
+}
+
+// isPrivateNetworkReady reports whether the Private Network has a cidr range
+// and all its instances are set up.
+func isPrivateNetworkReady(privateNetwork openapi.PrivateNetworkResponse) bool {
+	if privateNetwork.Cidr == "" {
+		return false
+	}
+	for _, instance := range privateNetwork.Instances {
+		if instance.Status != "ok" {
+			return false
+		}
+	}
+	return true
+}
+
+// countReadyInstances returns the number of instances of the Private Network
+// which report the status `ok`.
+func countReadyInstances(privateNetwork openapi.PrivateNetworkResponse) int {
+	count := 0
+	for _, instance := range privateNetwork.Instances {
+		if instance.Status == "ok" {
+			count++
+		}
+	}
+	return count
+}
+
+// privateNetworkingAddOnId identifies the private networking add-on in the
+// add-ons of an instance.
+const privateNetworkingAddOnId = 1477
+
+// maxConcurrentInstanceReads bounds the parallel instance detail lookups.
+const maxConcurrentInstanceReads = 4
+
+// AddInstanceDetailsToData adds the details the Private Network does not
+// report to its instances, i.e. whether they have the private networking
+// add-on, which image and OS they run and their region.
+func AddInstanceDetailsToData(
+	ctx context.Context,
+	client *openapi.APIClient,
+	d *schema.ResourceData,
+	diags diag.Diagnostics,
+) diag.Diagnostics {
+	instances := d.Get("instances").([]interface{})
+
+	instanceIds := make([]int64, 0, len(instances))
+	for _, instance := range instances {
+		instanceIds = append(instanceIds, int64(instance.(map[string]interface{})["instance_id"].(int)))
+	}
+
+	details, readDiags := fetchInstanceDetails(ctx, client, instanceIds)
+	diags = append(diags, readDiags...)
+
+	for i, instance := range instances {
+		instance := instance.(map[string]interface{})
+		instanceDetails, ok := details[instanceIds[i]]
+		if !ok {
+			continue
+		}
+		instance["has_private_networking_addon"] = hasPrivateNetworkingAddOn(instanceDetails)
+		instance["image_id"] = instanceDetails.ImageId
+		instance["os_type"] = instanceDetails.OsType
+		instance["region"] = instanceDetails.Region
+	}
+
+	if err := d.Set("instances", instances); err != nil {
+		return diag.FromErr(err)
+	}
+	return diags
+}
+
+// fetchInstanceDetails reads the instances in parallel, at most
+// maxConcurrentInstanceReads at a time, and returns them by their id. Failed
+// reads, e.g. of an instance deleted meanwhile, only warn and their instances
+// are missing in the result.
+func fetchInstanceDetails(
+	ctx context.Context,
+	client *openapi.APIClient,
+	instanceIds []int64,
+) (map[int64]openapi.InstanceResponse, diag.Diagnostics) {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var diags diag.Diagnostics
+	details := map[int64]openapi.InstanceResponse{}
+	semaphore := make(chan struct{}, maxConcurrentInstanceReads)
+
+	for _, instanceId := range instanceIds {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(instanceId int64) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			res, httpResp, err := client.InstancesApi.
+				RetrieveInstance(ctx, instanceId).
+				XRequestId(uuid.NewV4().String()).
+				Execute()
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			var readDiags diag.Diagnostics
+			if err != nil {
+				_, readDiags = HandleApiError(readDiags, httpResp, err)
+			} else if len(res.Data) != 1 {
+				readDiags = MultipleDataObjectsError(readDiags)
+			} else {
+				details[instanceId] = res.Data[0]
+				return
+			}
+			for _, readDiag := range readDiags {
+				readDiag.Severity = diag.Warning
+				readDiag.Summary = fmt.Sprintf("Could not read the details of instance %d: %s", instanceId, readDiag.Summary)
+				diags = append(diags, readDiag)
+			}
+		}(instanceId)
+	}
+	wg.Wait()
+
+	return details, diags
+}
+
+func hasPrivateNetworkingAddOn(instance openapi.InstanceResponse) bool {
+	for _, addOn := range instance.AddOns {
+		if addOn.Id == privateNetworkingAddOnId {
+			return true
+		}
+	}
+	return false
+}
+
+// privateNetworkInstanceHash is the Set hash for instance blocks of a Private
+// Network. It only covers the instance_id, so that computed attributes like
+// the private IPs do not change the hash and cause perpetual diffs.
+func privateNetworkInstanceHash(v interface{}) int {
+	instance := v.(map[string]interface{})
+	return schema.HashString(fmt.Sprint(instance["instance_id"]))
+}
+
 func buildInstanceIpConfig(instance openapi.Instances) map[string]interface{} {
 	instanceConfig := make(map[string]interface{})
 
@@ -2,12 +2,19 @@ package contabo
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"contabo.com/openapi"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	uuid "github.com/satori/go.uuid"
 )
@@ -45,7 +52,7 @@ func TestAccContaboPrivateNetworkBasic(t *testing.T) {
 }
 
 func testAccCheckPrivateNetworkDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*openapi.APIClient)
+	client := testAccProvider.Meta().(*providerMeta).client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "contabo_private_network" {
@@ -121,3 +128,2045 @@ func testCheckContaboPrivateNetworkExists(n string) resource.TestCheckFunc {
 		return nil
 	}
 }
+
+func testPrivateNetworkInstanceJson(instanceId int, status string) string {
+	return fmt.Sprintf(
+		`{"instanceId":%[1]d,"displayName":"","name":"vmd%[1]d","privateIpConfig":{"v4":[{"ip":"10.0.0.%[1]d","netmaskCidr":22,"gateway":"10.0.0.254"}]},"status":"%[2]s","errorMessage":null}`,
+		instanceId, status)
+}
+
+func testInstanceWithPrivateNetworkingJson(instanceId int) string {
+	return strings.Replace(
+		testInstanceJson(instanceId, "running"),
+		`"addOns":[]`,
+		fmt.Sprintf(`"addOns":[{"id":%d,"quantity":1}]`, privateNetworkingAddOnId),
+		1)
+}
+
+func testPrivateNetworkJson(privateNetworkId int, name string, instances ...string) string {
+	instancesJson := ""
+	for i, instance := range instances {
+		if i > 0 {
+			instancesJson += ","
+		}
+		instancesJson += instance
+	}
+	return fmt.Sprintf(
+		`{"tenantId":"DE","customerId":"54321","privateNetworkId":%d,"dataCenter":"European Union 1","region":"EU","regionName":"European Union","name":"%s","description":"","cidr":"10.0.0.0/22","availableIps":1019,"createdDate":"2022-01-01T00:00:00Z","instances":[%s]}`,
+		privateNetworkId, name, instancesJson)
+}
+
+func TestPrivateNetworkCreateAdoptsExisting(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks":
+			if r.URL.Query().Get("name") != "database" {
+				t.Fatalf("expected a lookup by name, got %s", r.URL)
+			}
+			writeJson(w, http.StatusOK, listBody(testPrivateNetworkJson(100, "database")+","+testPrivateNetworkJson(101, "database-old"), 2))
+		case r.Method == http.MethodPatch && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "database")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "database")+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":           "database",
+		"region":         "EU",
+		"adopt_existing": true,
+	})
+
+	if diags := resourcePrivateNetworkCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Id() != "100" {
+		t.Fatalf("expected the existing Private Network 100 to be adopted, got %q", d.Id())
+	}
+}
+
+func TestPrivateNetworkCreateAdoptAmbiguousName(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/private-networks" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		writeJson(w, http.StatusOK, listBody(testPrivateNetworkJson(100, "database")+","+testPrivateNetworkJson(102, "database"), 2))
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":           "database",
+		"adopt_existing": true,
+	})
+
+	if diags := resourcePrivateNetworkCreate(context.Background(), d, meta); !diags.HasError() {
+		t.Fatal("expected an error for an ambiguous name")
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected no Private Network to be adopted, got %q", d.Id())
+	}
+}
+
+func TestPrivateNetworkCreateAdoptFindsNamesOnAllPages(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/private-networks" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		page := r.URL.Query().Get("page")
+		privateNetworkJson := testPrivateNetworkJson(100, "database")
+		if page == "2" {
+			privateNetworkJson = testPrivateNetworkJson(102, "database")
+		}
+		writeJson(w, http.StatusOK, fmt.Sprintf(
+			`{"_pagination":{"size":1,"totalElements":2,"totalPages":2,"page":%s},"data":[%s],"_links":{"self":"/"}}`,
+			page, privateNetworkJson))
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":           "database",
+		"adopt_existing": true,
+	})
+
+	diags := resourcePrivateNetworkCreate(context.Background(), d, meta)
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "[100 102]") {
+		t.Fatalf("expected the name to be ambiguous across pages, got %v", diags)
+	}
+}
+
+func TestPrivateNetworkCreateEnforcesUniqueNames(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/private-networks" {
+			t.Fatalf("a Private Network with a taken name must not be created, got %s %s", r.Method, r.URL)
+		}
+		writeJson(w, http.StatusOK, listBody(testPrivateNetworkJson(100, "database")+","+testPrivateNetworkJson(101, "database-old"), 2))
+	}))
+	meta.enforceUniqueNames = true
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name": "database",
+	})
+
+	diags := resourcePrivateNetworkCreate(context.Background(), d, meta)
+	if !diags.HasError() || diags[0].Summary != "Private Network name already taken" || !strings.Contains(diags[0].Detail, "100") {
+		t.Fatalf("expected the duplicate name to be rejected, got %v", diags)
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected no Private Network to be created, got %q", d.Id())
+	}
+}
+
+func TestPrivateNetworkCreateWithTags(t *testing.T) {
+	assignedTags := map[string]bool{}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks":
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "tagged")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/tags/7/assignments/private-network/100":
+			assignedTags["7"] = true
+			writeJson(w, http.StatusCreated, `{"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/tags/8/assignments/private-network/100":
+			writeJson(w, http.StatusNotFound, `{"statusCode":404,"message":"Entry Tag not found"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "tagged")+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name": "tagged",
+		"tags": []interface{}{7, 8},
+	})
+
+	diags := resourcePrivateNetworkCreate(context.Background(), d, meta)
+
+	if diags.HasError() {
+		t.Fatalf("a failed tag assignment must not fail the create: %v", diags)
+	}
+	if len(diags) != 1 {
+		t.Fatalf("expected a warning about tag 8, got %v", diags)
+	}
+	if !assignedTags["7"] {
+		t.Fatal("expected tag 7 to be assigned")
+	}
+	tags := d.Get("tags").(*schema.Set)
+	if tags.Len() != 1 || !tags.Contains(7) {
+		t.Fatalf("expected only the assigned tag 7 in the state, got %v", tags.List())
+	}
+}
+
+func TestUnassignInstancesFromPrivateNetworkInParallel(t *testing.T) {
+	var mutex sync.Mutex
+	inFlight, maxInFlight, calls := 0, 0, 0
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		mutex.Lock()
+		inFlight++
+		calls++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mutex.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mutex.Lock()
+		inFlight--
+		mutex.Unlock()
+
+		switch r.URL.Path {
+		case "/v1/private-networks/100/instances/3":
+			writeJson(w, http.StatusNotFound, `{"statusCode":404,"message":"Entry Instance not found"}`)
+		case "/v1/private-networks/100/instances/5":
+			writeJson(w, http.StatusInternalServerError, `{"statusCode":500,"message":"Internal Server Error"}`)
+		default:
+			writeJson(w, http.StatusNoContent, ``)
+		}
+	}))
+
+	instanceIds := []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	diags := unassignInstancesFromPrivateNetwork(context.Background(), nil, meta.client, 100, instanceIds)
+
+	if calls != len(instanceIds) {
+		t.Fatalf("expected %d unassign calls, got %d", len(instanceIds), calls)
+	}
+	if maxInFlight > maxConcurrentUnassigns {
+		t.Fatalf("expected at most %d concurrent calls, got %d", maxConcurrentUnassigns, maxInFlight)
+	}
+	if maxInFlight < 2 {
+		t.Fatalf("expected the unassign calls to run in parallel, got %d concurrent calls", maxInFlight)
+	}
+	if len(diags) != 1 || !diags.HasError() {
+		t.Fatalf("expected only the failed unassign of instance 5 to be reported, got %v", diags)
+	}
+}
+
+func TestAddPrivateNetworkToDataSortsInstances(t *testing.T) {
+	var res openapi.FindPrivateNetworkResponse
+	body := `{"data":[` + testPrivateNetworkJson(100, "unordered",
+		testPrivateNetworkInstanceJson(300, "ok"),
+		testPrivateNetworkInstanceJson(100, "ok"),
+		testPrivateNetworkInstanceJson(200, "ok")) + `],"_links":{"self":"/"}}`
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{})
+	if diags := AddPrivateNetworkToData(res.Data[0], d, nil); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	instances := d.Get("instances").([]interface{})
+	for i, expectedId := range []int{100, 200, 300} {
+		if instanceId := instances[i].(map[string]interface{})["instance_id"].(int); instanceId != expectedId {
+			t.Fatalf("expected instance %d at position %d, got %d", expectedId, i, instanceId)
+		}
+	}
+}
+
+func TestPrivateNetworkDeleteWaitsForDeletion(t *testing.T) {
+	deleted := false
+	pollsAfterDelete := 0
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			if deleted {
+				pollsAfterDelete++
+				if pollsAfterDelete > 2 {
+					writeJson(w, http.StatusNotFound, `{"statusCode":404,"message":"Entry PrivateNetwork not found"}`)
+					return
+				}
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "lingering")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/private-networks/100":
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"wait_for_deletion": true,
+	})
+	d.SetId("100")
+
+	if diags := resourcePrivateNetworkDelete(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if pollsAfterDelete != 3 {
+		t.Fatalf("expected to poll until the network is gone, polled %d times", pollsAfterDelete)
+	}
+	if d.Id() != "" {
+		t.Fatal("expected the id to be removed")
+	}
+}
+
+func TestPrivateNetworkDeletionWaitStopsAfterTimeout(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/private-networks/100" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "lingering")+`],"_links":{"self":"/"}}`)
+	}))
+	meta.pollInterval = time.Millisecond
+
+	diags := waitForPrivateNetworkDeletion(context.Background(), nil, meta, 100, 20*time.Millisecond)
+	if !diags.HasError() {
+		t.Fatal("expected an error when the Private Network is not gone in time")
+	}
+}
+
+func TestPrivateNetworkReadSetsS3UrlOfDataCenter(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/private-networks/100" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "regional")+`],"_links":{"self":"/"}}`)
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{})
+	d.SetId("100")
+
+	if diags := resourcePrivateNetworkRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	// the network is located in "European Union 1"
+	if s3Url := d.Get("s3_url").(string); s3Url != "https://eu2.contabostorage.com" {
+		t.Fatalf("expected the s3 url of the network's data center, got %q", s3Url)
+	}
+}
+
+func TestAddDataCenterS3UrlToDataKeepsDiagnostics(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+	}))
+
+	// without s3_url in the schema setting it fails
+	d := schema.TestResourceDataRaw(t, map[string]*schema.Schema{}, map[string]interface{}{})
+	diags := diag.Diagnostics{{Severity: diag.Warning, Summary: "earlier warning"}}
+
+	diags = AddDataCenterS3UrlToData(context.Background(), meta.client, "European Union 1", d, diags)
+	if len(diags) != 2 || diags[0].Summary != "earlier warning" || !diags.HasError() {
+		t.Fatalf("expected the earlier warning to be kept along with the error, got %v", diags)
+	}
+}
+
+func TestPrivateNetworkDeleteRetriesTransientRead(t *testing.T) {
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = time.Second }()
+
+	reads := 0
+	deleted := false
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			reads++
+			if reads == 1 {
+				writeJson(w, http.StatusServiceUnavailable, `{"statusCode":503,"message":"Service Unavailable"}`)
+				return
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "flaky")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/private-networks/100":
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{})
+	d.SetId("100")
+
+	if diags := resourcePrivateNetworkDelete(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("expected the transient error to be retried, got %v", diags)
+	}
+	if reads != 2 || !deleted {
+		t.Fatalf("expected a retried read and a delete, got %d reads and deleted=%t", reads, deleted)
+	}
+}
+
+func TestPrivateNetworkDeleteUnassignsInParallel(t *testing.T) {
+	var mutex sync.Mutex
+	inFlight, maxInFlight, unassigns := 0, 0, 0
+	unassignsBeforeDelete := -1
+	instances := []string{}
+	for instanceId := 1; instanceId <= 8; instanceId++ {
+		instances = append(instances, testPrivateNetworkInstanceJson(instanceId, "ok"))
+	}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "crowded", instances...)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/private-networks/100":
+			mutex.Lock()
+			unassignsBeforeDelete = unassigns
+			mutex.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/private-networks/100/instances/"):
+			mutex.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mutex.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mutex.Lock()
+			inFlight--
+			unassigns++
+			mutex.Unlock()
+
+			// instance 3 was already unassigned
+			if r.URL.Path == "/v1/private-networks/100/instances/3" {
+				writeJson(w, http.StatusNotFound, `{"statusCode":404,"message":"Entry Instance not found"}`)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{})
+	d.SetId("100")
+
+	if diags := resourcePrivateNetworkDelete(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if unassignsBeforeDelete != len(instances) {
+		t.Fatalf("expected all %d unassigns to complete before the delete, got %d", len(instances), unassignsBeforeDelete)
+	}
+	if maxInFlight < 2 || maxInFlight > maxConcurrentUnassigns {
+		t.Fatalf("expected between 2 and %d concurrent unassigns, got %d", maxConcurrentUnassigns, maxInFlight)
+	}
+}
+
+func TestPrivateNetworkDeleteAlreadyGone(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/private-networks/100" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		writeJson(w, http.StatusNotFound, `{"statusCode":404,"message":"Entry PrivateNetwork not found"}`)
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{})
+	d.SetId("100")
+
+	if diags := resourcePrivateNetworkDelete(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("expected a missing network to count as deleted, got %v", diags)
+	}
+	if d.Id() != "" {
+		t.Fatal("expected the id to be removed")
+	}
+}
+
+func TestPrivateNetworkReadFlagsPrivateNetworkingAddOn(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "addons",
+				testPrivateNetworkInstanceJson(10, "ok"),
+				testPrivateNetworkInstanceJson(20, "ok"))+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/10":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(10)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/20":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceJson(20, "running")+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"include_instance_details": true,
+	})
+	d.SetId("100")
+
+	if diags := resourcePrivateNetworkRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	instances := d.Get("instances").([]interface{})
+	if !instances[0].(map[string]interface{})["has_private_networking_addon"].(bool) {
+		t.Fatal("expected instance 10 to have the private networking add-on")
+	}
+	if instances[1].(map[string]interface{})["has_private_networking_addon"].(bool) {
+		t.Fatal("expected instance 20 not to have the private networking add-on")
+	}
+}
+
+func TestPrivateNetworkReadSkipsInstanceDetailsByDefault(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/private-networks/100" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "plain",
+			testPrivateNetworkInstanceJson(10, "ok"))+`],"_links":{"self":"/"}}`)
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{})
+	d.SetId("100")
+	if diags := resourcePrivateNetworkRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected resource error: %v", diags)
+	}
+
+	dataSourceData := schema.TestResourceDataRaw(t, dataSourcePrivateNetwork().Schema, map[string]interface{}{})
+	dataSourceData.SetId("100")
+	if diags := dataSourcePrivateNetworkRead(context.Background(), dataSourceData, meta); diags.HasError() {
+		t.Fatalf("unexpected data source error: %v", diags)
+	}
+}
+
+func TestPrivateNetworkLastRequestIdChangesOnUpdate(t *testing.T) {
+	var lastMutatingRequestId string
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks":
+			lastMutatingRequestId = r.Header.Get("x-request-id")
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "audited")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPatch && r.URL.Path == "/v1/private-networks/100":
+			lastMutatingRequestId = r.Header.Get("x-request-id")
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "audited")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "audited")+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name": "audited",
+	})
+	if diags := resourcePrivateNetworkCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected create error: %v", diags)
+	}
+	createRequestId := d.Get("last_request_id").(string)
+	if createRequestId == "" || createRequestId != lastMutatingRequestId {
+		t.Fatalf("expected the create request id %q, got %q", lastMutatingRequestId, createRequestId)
+	}
+
+	d.Set("description", "renamed")
+	if diags := resourcePrivateNetworkUpdate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected update error: %v", diags)
+	}
+	updateRequestId := d.Get("last_request_id").(string)
+	if updateRequestId == createRequestId || updateRequestId != lastMutatingRequestId {
+		t.Fatalf("expected the update request id %q, got %q", lastMutatingRequestId, updateRequestId)
+	}
+}
+
+func TestParsePrivateNetworkImportIds(t *testing.T) {
+	cases := map[string][]string{
+		"100":           {"100"},
+		"100,200":       {"100", "200"},
+		" 100 , 200 ,3": {"100", "200", "3"},
+		"all":           {"all"},
+	}
+	for importId, expected := range cases {
+		ids, err := parsePrivateNetworkImportIds(importId)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", importId, err)
+		}
+		if strings.Join(ids, ",") != strings.Join(expected, ",") {
+			t.Fatalf("expected %v for %q, got %v", expected, importId, ids)
+		}
+	}
+
+	for _, importId := range []string{"", "100,", "100,network", "all,100"} {
+		if _, err := parsePrivateNetworkImportIds(importId); err == nil {
+			t.Fatalf("expected an error for %q", importId)
+		}
+	}
+}
+
+func TestPrivateNetworkImportMultipleIds(t *testing.T) {
+	d := resourcePrivateNetwork().Data(nil)
+	d.SetId("100,200")
+
+	results, err := resourcePrivateNetworkImport(context.Background(), d, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].Id() != "100" || results[1].Id() != "200" {
+		t.Fatalf("expected the Private Networks 100 and 200 to be imported, got %v", results)
+	}
+}
+
+func TestPrivateNetworkCreateHonorsMaxRetries(t *testing.T) {
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = time.Second }()
+
+	upgrades := 0
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks":
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "retried")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/compute/instances/200/upgrade":
+			upgrades++
+			writeJson(w, http.StatusBadRequest, `{"statusCode":400,"message":"Instance is still provisioning"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/200":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceJson(200, "provisioning")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "retried")+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	meta.maxRetries = 2
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":         "retried",
+		"instance_ids": []interface{}{200},
+	})
+
+	if diags := resourcePrivateNetworkCreate(context.Background(), d, meta); !diags.HasError() {
+		t.Fatal("expected an error once the retries are exhausted")
+	}
+	if upgrades != 3 {
+		t.Fatalf("expected the add-on upgrade to be tried once and retried twice, got %d calls", upgrades)
+	}
+}
+
+func TestPrivateNetworkAddOnRetriesStopOnCancellation(t *testing.T) {
+	retryDelay = time.Minute
+	defer func() { retryDelay = time.Second }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	upgrades := 0
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/compute/instances/200/upgrade":
+			upgrades++
+			// Ctrl-C while the add-on upgrade fails
+			cancel()
+			writeJson(w, http.StatusInternalServerError, `{"statusCode":500,"message":"Internal Server Error"}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	start := time.Now()
+	diags := addInstancesToPrivateNetwork(ctx, nil, meta, 100, []interface{}{200})
+	if !diags.HasError() {
+		t.Fatal("expected the cancelled add-on upgrade to fail")
+	}
+	if upgrades != 1 || time.Since(start) > 10*time.Second {
+		t.Fatalf("expected the retries to stop right after the cancellation, got %d attempts in %v", upgrades, time.Since(start))
+	}
+}
+
+func TestPrivateNetworkCreateRetriesConflictingAssignment(t *testing.T) {
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = time.Second }()
+
+	assignments := 0
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks":
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "rebooting")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/compute/instances/200/upgrade":
+			writeJson(w, http.StatusOK, `{"data":[{"tenantId":"DE","customerId":"54321","instanceId":200}],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks/100/instances/200":
+			// the instance is rebooting during the first two attempts
+			assignments++
+			if assignments <= 2 {
+				writeJson(w, http.StatusConflict, `{"statusCode":409,"message":"Instance has an operation in progress"}`)
+				return
+			}
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "rebooting")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			instances := []string{}
+			if assignments > 2 {
+				instances = append(instances, testPrivateNetworkInstanceJson(200, "ok"))
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "rebooting", instances...)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/200":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(200)+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":         "rebooting",
+		"instance_ids": []interface{}{200},
+	})
+
+	if diags := resourcePrivateNetworkCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if assignments != 3 {
+		t.Fatalf("expected the assignment to be retried until it succeeded, got %d attempts", assignments)
+	}
+	if instanceIds := d.Get("instance_ids").(*schema.Set); !instanceIds.Contains(200) {
+		t.Fatalf("expected instance 200 in the state, got %v", instanceIds.List())
+	}
+}
+
+func TestPrivateNetworkRetriesTransientlyFailingAssignment(t *testing.T) {
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = time.Second }()
+
+	requestIds := []string{}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/compute/instances/200/upgrade":
+			writeJson(w, http.StatusOK, `{"data":[{"tenantId":"DE","customerId":"54321","instanceId":200}],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "flaky")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks/100/instances/200":
+			requestIds = append(requestIds, r.Header.Get("x-request-id"))
+			if len(requestIds) == 1 {
+				writeJson(w, http.StatusServiceUnavailable, `{"statusCode":503,"message":"Service Unavailable"}`)
+				return
+			}
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "flaky")+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	if diags := addInstancesToPrivateNetwork(context.Background(), nil, meta, 100, []interface{}{200}); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if len(requestIds) != 2 {
+		t.Fatalf("expected the assignment to be retried once after the transient failure, got %d attempts", len(requestIds))
+	}
+	if requestIds[0] == "" || requestIds[0] == requestIds[1] {
+		t.Fatalf("expected every attempt to have its own request id, got %v", requestIds)
+	}
+}
+
+func TestPrivateNetworkCreateSkipsExternallyAssignedInstance(t *testing.T) {
+	externallyAssigned := false
+	assigned := []string{}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks":
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "raced")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/compute/instances/200/upgrade":
+			// someone else assigns the instance while we upgrade it
+			externallyAssigned = true
+			writeJson(w, http.StatusOK, `{"data":[{"tenantId":"DE","customerId":"54321","instanceId":200}],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/compute/instances/300/upgrade":
+			writeJson(w, http.StatusOK, `{"data":[{"tenantId":"DE","customerId":"54321","instanceId":300}],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks/100/instances/200":
+			writeJson(w, http.StatusConflict, `{"statusCode":409,"message":"Instance already assigned"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks/100/instances/300":
+			assigned = append(assigned, "300")
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "raced")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			instances := []string{}
+			if externallyAssigned {
+				instances = append(instances, testPrivateNetworkInstanceJson(200, "ok"))
+			}
+			if len(assigned) > 0 {
+				instances = append(instances, testPrivateNetworkInstanceJson(300, "ok"))
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "raced", instances...)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/200":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(200)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/300":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(300)+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":         "raced",
+		"instance_ids": []interface{}{200, 300},
+	})
+
+	if diags := resourcePrivateNetworkCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if len(assigned) != 1 {
+		t.Fatalf("expected only instance 300 to be assigned, got %v", assigned)
+	}
+	if instanceIds := d.Get("instance_ids").(*schema.Set); instanceIds.Len() != 2 {
+		t.Fatalf("expected both instances in the network, got %v", instanceIds.List())
+	}
+}
+
+func TestPrivateNetworkCountsReadyMembers(t *testing.T) {
+	var privateNetwork openapi.PrivateNetworkResponse
+	if err := json.Unmarshal([]byte(testPrivateNetworkJson(100, "mixed",
+		testPrivateNetworkInstanceJson(10, "ok"),
+		testPrivateNetworkInstanceJson(20, "installing"),
+		testPrivateNetworkInstanceJson(30, "ok"),
+		testPrivateNetworkInstanceJson(40, "restart"),
+		testPrivateNetworkInstanceJson(50, "ok"))), &privateNetwork); err != nil {
+		t.Fatal(err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{})
+	if diags := AddPrivateNetworkToData(privateNetwork, d, nil); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if count := d.Get("members_ready_count").(int); count != 3 {
+		t.Fatalf("expected 3 of the 5 instances to be ready, got %d", count)
+	}
+}
+
+func TestPrivateNetworkIdAsNumber(t *testing.T) {
+	var privateNetwork openapi.PrivateNetworkResponse
+	if err := json.Unmarshal([]byte(testPrivateNetworkJson(12345, "numbered")), &privateNetwork); err != nil {
+		t.Fatal(err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{})
+	if diags := AddPrivateNetworkToData(privateNetwork, d, nil); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	if id, privateNetworkId := d.Get("id").(string), d.Get("private_network_id").(int); id != "12345" || strconv.Itoa(privateNetworkId) != id {
+		t.Fatalf("expected the id 12345 as string and number, got %q and %d", id, privateNetworkId)
+	}
+}
+
+func TestPrivateNetworkDatesInRfc3339(t *testing.T) {
+	var privateNetwork openapi.PrivateNetworkResponse
+	if err := json.Unmarshal([]byte(strings.Replace(testPrivateNetworkJson(100, "dates"),
+		`"createdDate":"2022-01-01T00:00:00Z"`, `"createdDate":"2022-03-14T15:09:26Z"`, 1)), &privateNetwork); err != nil {
+		t.Fatal(err)
+	}
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{})
+	if diags := AddPrivateNetworkToData(privateNetwork, d, nil); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	createdDate, err := time.Parse(time.RFC850, d.Get("created_date").(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	createdDateRfc3339, err := time.Parse(time.RFC3339, d.Get("created_date_rfc3339").(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !createdDate.Equal(createdDateRfc3339) || !createdDate.Equal(privateNetwork.CreatedDate) {
+		t.Fatalf("expected both formats to be the creation date %v, got %v and %v", privateNetwork.CreatedDate, createdDate, createdDateRfc3339)
+	}
+}
+
+func TestPrivateNetworkReadyWhileInstanceInstalling(t *testing.T) {
+	installing := true
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			status := "ok"
+			if installing {
+				status = "installing"
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "gated",
+				testPrivateNetworkInstanceJson(10, "ok"),
+				testPrivateNetworkInstanceJson(20, status))+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && (r.URL.Path == "/v1/compute/instances/10" || r.URL.Path == "/v1/compute/instances/20"):
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(10)+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{})
+	d.SetId("100")
+
+	if diags := resourcePrivateNetworkRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if d.Get("ready").(bool) {
+		t.Fatal("expected the network not to be ready while instance 20 is installing")
+	}
+
+	installing = false
+	if diags := resourcePrivateNetworkRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if !d.Get("ready").(bool) {
+		t.Fatal("expected the network to be ready once all instances are ok")
+	}
+}
+
+func TestPrivateNetworkCreateCollectsFailedInstances(t *testing.T) {
+	assigned := map[int]bool{}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var instanceId int
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks":
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "partial")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/compute/instances/2/upgrade":
+			writeJson(w, http.StatusBadRequest, `{"statusCode":400,"message":"Instance is still provisioning"}`)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/upgrade"):
+			writeJson(w, http.StatusOK, `{"data":[{"tenantId":"DE","customerId":"54321","instanceId":1}],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && sscanfPath(r.URL.Path, "/v1/private-networks/100/instances/%d", &instanceId):
+			assigned[instanceId] = true
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "partial")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			instances := []string{}
+			for _, id := range []int{1, 2, 3} {
+				if assigned[id] {
+					instances = append(instances, testPrivateNetworkInstanceJson(id, "ok"))
+				}
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "partial", instances...)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && sscanfPath(r.URL.Path, "/v1/compute/instances/%d", &instanceId):
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(instanceId)+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	meta.maxRetries = 0
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":         "partial",
+		"instance_ids": []interface{}{1, 2, 3},
+	})
+
+	diags := resourcePrivateNetworkCreate(context.Background(), d, meta)
+
+	if !diags.HasError() || !strings.Contains(diags[0].Detail, "instance 2:") {
+		t.Fatalf("expected an error listing instance 2, got %v", diags)
+	}
+	if strings.Contains(diags[0].Detail, "instance 1:") || strings.Contains(diags[0].Detail, "instance 3:") {
+		t.Fatalf("expected only instance 2 to fail, got %v", diags[0].Detail)
+	}
+	if d.Id() != "100" {
+		t.Fatalf("expected the created network to stay in the state, got %q", d.Id())
+	}
+	instanceIds := d.Get("instance_ids").(*schema.Set)
+	if instanceIds.Len() != 2 || !instanceIds.Contains(1) || !instanceIds.Contains(3) {
+		t.Fatalf("expected instances 1 and 3 in the state, got %v", instanceIds.List())
+	}
+}
+
+func TestPrivateNetworkCreateAssignsInstancesInOrder(t *testing.T) {
+	assigned := []int{}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var instanceId int
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks":
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "ordered")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/upgrade"):
+			writeJson(w, http.StatusOK, `{"data":[{"tenantId":"DE","customerId":"54321","instanceId":1}],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && sscanfPath(r.URL.Path, "/v1/private-networks/100/instances/%d", &instanceId):
+			assigned = append(assigned, instanceId)
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "ordered")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			instances := []string{}
+			for _, id := range assigned {
+				instances = append(instances, testPrivateNetworkInstanceJson(id, "ok"))
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "ordered", instances...)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && sscanfPath(r.URL.Path, "/v1/compute/instances/%d", &instanceId):
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(instanceId)+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":           "ordered",
+		"instance_ids":   []interface{}{1, 2, 3, 4},
+		"instance_order": []interface{}{3, 1, 7},
+	})
+
+	if diags := resourcePrivateNetworkCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if len(assigned) != 4 || assigned[0] != 3 || assigned[1] != 1 {
+		t.Fatalf("expected instance 3 and 1 to be assigned first, got %v", assigned)
+	}
+}
+
+func TestPrivateNetworkCreateWaitsForPrivateIp(t *testing.T) {
+	polls := 0
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var instanceId int
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks":
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "waiting")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/upgrade"):
+			writeJson(w, http.StatusOK, `{"data":[{"tenantId":"DE","customerId":"54321","instanceId":1}],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks/100/instances/1":
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "waiting")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			polls++
+			instance := testPrivateNetworkInstanceJson(1, "ok")
+			if polls <= 2 {
+				instance = `{"instanceId":1,"displayName":"","name":"vmd1","privateIpConfig":{"v4":[]},"status":"ok","errorMessage":null}`
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "waiting", instance)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && sscanfPath(r.URL.Path, "/v1/compute/instances/%d", &instanceId):
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(instanceId)+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	meta.pollInterval = time.Millisecond
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":                "waiting",
+		"instance_ids":        []interface{}{1},
+		"wait_for_private_ip": true,
+	})
+
+	if diags := resourcePrivateNetworkCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	// two polls without the address, one with it and the final read
+	if polls != 4 {
+		t.Fatalf("expected the create to wait until the third poll, got %d reads", polls)
+	}
+	if ip := d.Get("instances.0.private_ip_config.0.v4.0.ip").(string); ip != "10.0.0.1" {
+		t.Fatalf("expected the private IP 10.0.0.1 in the state, got %q", ip)
+	}
+}
+
+func TestFetchInstanceDetailsBoundsConcurrency(t *testing.T) {
+	var mutex sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mutex.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		defer func() {
+			mutex.Lock()
+			inFlight--
+			mutex.Unlock()
+		}()
+
+		var instanceId int
+		switch {
+		case sscanfPath(r.URL.Path, "/v1/compute/instances/%d", &instanceId) && instanceId%5 == 0:
+			writeJson(w, http.StatusNotFound, `{"statusCode":404,"message":"Entry Instances not found"}`)
+		case sscanfPath(r.URL.Path, "/v1/compute/instances/%d", &instanceId):
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceJson(instanceId, "running")+`],"_links":{"self":"/"}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	instanceIds := []int64{}
+	for id := int64(1); id <= 12; id++ {
+		instanceIds = append(instanceIds, id)
+	}
+	details, diags := fetchInstanceDetails(context.Background(), meta.client, instanceIds)
+
+	if len(diags) != 2 || diags.HasError() {
+		t.Fatalf("expected a warning for each of instance 5 and 10, got %v", diags)
+	}
+	if len(details) != 10 {
+		t.Fatalf("expected the details of the other 10 instances, got %d", len(details))
+	}
+	for _, instanceId := range instanceIds {
+		if _, ok := details[instanceId]; ok == (instanceId%5 == 0) {
+			t.Fatalf("unexpected details of instance %d: %v", instanceId, details[instanceId])
+		}
+	}
+	if maxInFlight > maxConcurrentInstanceReads {
+		t.Fatalf("expected at most %d concurrent reads, got %d", maxConcurrentInstanceReads, maxInFlight)
+	}
+}
+
+// sscanfPath reports whether the path matches the format exactly.
+func sscanfPath(path string, format string, ids ...*int) bool {
+	targets, values := []interface{}{}, []interface{}{}
+	for _, id := range ids {
+		targets = append(targets, id)
+	}
+	n, err := fmt.Sscanf(path, format, targets...)
+	if err != nil || n != len(ids) {
+		return false
+	}
+	for _, id := range ids {
+		values = append(values, *id)
+	}
+	return fmt.Sprintf(format, values...) == path
+}
+
+func TestPrivateNetworkInstanceHashIgnoresIps(t *testing.T) {
+	instance := buildInstanceIpConfig(openapi.Instances{InstanceId: 100})
+	hash := privateNetworkInstanceHash(instance)
+
+	// the same instance after the API assigned it an IP
+	assigned := buildInstanceIpConfig(openapi.Instances{
+		InstanceId: 100,
+		Status:     "ok",
+		PrivateIpConfig: openapi.PrivateIpConfig{V4: []openapi.IpV4{
+			{Ip: "10.0.0.1", NetmaskCidr: 22, Gateway: "10.0.0.254"},
+		}},
+	})
+	if privateNetworkInstanceHash(assigned) != hash {
+		t.Fatal("expected the hash to stay the same when the IPs change")
+	}
+
+	if privateNetworkInstanceHash(buildInstanceIpConfig(openapi.Instances{InstanceId: 101})) == hash {
+		t.Fatal("expected different instances to have different hashes")
+	}
+}
+
+func TestPrivateNetworkGatewayIsFirstUsableHost(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/private-networks/100" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		// the cidr is 10.0.0.0/22
+		writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "routed")+`],"_links":{"self":"/"}}`)
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{})
+	d.SetId("100")
+
+	if diags := resourcePrivateNetworkRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if gateway := d.Get("network_gateway").(string); gateway != "10.0.0.1" {
+		t.Fatalf("expected the gateway 10.0.0.1, got %q", gateway)
+	}
+	if d.Get("cidr_first_ip") != "10.0.0.1" || d.Get("cidr_last_ip") != "10.0.3.254" || d.Get("cidr_host_count") != 1022 {
+		t.Fatalf("expected the hosts 10.0.0.1 to 10.0.3.254, got %v to %v (%v)",
+			d.Get("cidr_first_ip"), d.Get("cidr_last_ip"), d.Get("cidr_host_count"))
+	}
+
+	for cidr, expected := range map[string]string{
+		"192.168.4.0/24": "192.168.4.1",
+		"10.0.3.255/22":  "10.0.0.1",
+		"172.16.0.0/31":  "172.16.0.0",
+		"172.16.0.7/32":  "172.16.0.7",
+		"":               "",
+	} {
+		if gateway := networkGateway(cidr); gateway != expected {
+			t.Errorf("expected the gateway of %q to be %q, got %q", cidr, expected, gateway)
+		}
+	}
+}
+
+func TestPrivateNetworkDeleteBlockedByDeletionProtection(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"deletion_protection": true,
+	})
+	d.SetId("100")
+
+	if diags := resourcePrivateNetworkDelete(context.Background(), d, meta); !diags.HasError() {
+		t.Fatal("expected the delete of a protected Private Network to fail")
+	}
+	if d.Id() != "100" {
+		t.Fatalf("expected the protected Private Network to stay in the state, got %q", d.Id())
+	}
+}
+
+func TestPrivateNetworkCreateRejectsEmptyInstances(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":        "cluster-nodes",
+		"region":      "EU",
+		"allow_empty": false,
+	})
+
+	diags := resourcePrivateNetworkCreate(context.Background(), d, meta)
+	if !diags.HasError() || diags[0].Summary != "Private Network without instances" {
+		t.Fatalf("expected the create of an empty Private Network to fail, got %v", diags)
+	}
+	if d.Id() != "" {
+		t.Fatalf("expected no Private Network to be created, got %q", d.Id())
+	}
+}
+
+func TestPrivateNetworkRefreshWarnsAboutExternalRemoval(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			// instance 20 was removed outside of Terraform
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "drifted",
+				testPrivateNetworkInstanceJson(10, "ok"))+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/10":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(10)+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":         "drifted",
+		"instance_ids": []interface{}{10, 20},
+	})
+	d.SetId("100")
+
+	diags := resourcePrivateNetworkRefresh(context.Background(), d, meta)
+
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if len(diags) != 1 || diags[0].Severity != diag.Warning || !strings.Contains(diags[0].Detail, "instances [20] were removed") {
+		t.Fatalf("expected a warning about the removed instance 20, got %v", diags)
+	}
+	if instanceIds := d.Get("instance_ids").(*schema.Set); instanceIds.Len() != 1 || !instanceIds.Contains(10) {
+		t.Fatalf("expected the state to be updated to instance 10, got %v", instanceIds.List())
+	}
+
+	// a second refresh has nothing to report
+	if diags := resourcePrivateNetworkRefresh(context.Background(), d, meta); len(diags) != 0 {
+		t.Fatalf("expected no warning once the state is refreshed, got %v", diags)
+	}
+}
+
+func TestPrivateNetworkReadAddsInstanceOs(t *testing.T) {
+	instanceIds := []int{10, 20, 30, 40, 50, 60}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var instanceId int
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			instances := []string{}
+			for _, instanceId := range instanceIds {
+				instances = append(instances, testPrivateNetworkInstanceJson(instanceId, "ok"))
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "os", instances...)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && sscanfPath(r.URL.Path, "/v1/compute/instances/%d", &instanceId):
+			instance := testInstanceJson(instanceId, "running")
+			if instanceId == 20 {
+				instance = strings.Replace(instance, `"osType":"Linux"`, `"osType":"Windows"`, 1)
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+instance+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"include_instance_details": true,
+	})
+	d.SetId("100")
+
+	if diags := resourcePrivateNetworkRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	instances := d.Get("instances").([]interface{})
+	if len(instances) != len(instanceIds) {
+		t.Fatalf("expected %d instances, got %d", len(instanceIds), len(instances))
+	}
+	for _, instance := range instances {
+		instance := instance.(map[string]interface{})
+		expected := "Linux"
+		if instance["instance_id"].(int) == 20 {
+			expected = "Windows"
+		}
+		if instance["os_type"].(string) != expected {
+			t.Errorf("expected instance %d to run %s, got %q", instance["instance_id"], expected, instance["os_type"])
+		}
+		if instance["image_id"].(string) != "afecbb85-e2fc-46f0-9684-b46b1faf00bb" {
+			t.Errorf("expected the image of instance %d, got %q", instance["instance_id"], instance["image_id"])
+		}
+	}
+}
+
+func TestPrivateNetworkReadAddsInstanceRegion(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var instanceId int
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "regions",
+				testPrivateNetworkInstanceJson(10, "ok"),
+				testPrivateNetworkInstanceJson(20, "ok"))+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && sscanfPath(r.URL.Path, "/v1/compute/instances/%d", &instanceId):
+			instance := testInstanceJson(instanceId, "running")
+			if instanceId == 20 {
+				instance = strings.Replace(instance, `"region":"EU"`, `"region":"US-central"`, 1)
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+instance+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"include_instance_details": true,
+	})
+	d.SetId("100")
+
+	if diags := resourcePrivateNetworkRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	expected := map[int]string{10: "EU", 20: "US-central"}
+	for _, instance := range d.Get("instances").([]interface{}) {
+		instance := instance.(map[string]interface{})
+		if region := instance["region"].(string); region != expected[instance["instance_id"].(int)] {
+			t.Errorf("expected instance %d in %s, got %q", instance["instance_id"], expected[instance["instance_id"].(int)], region)
+		}
+	}
+}
+
+func TestPrivateNetworkReadSetsCreatedBy(t *testing.T) {
+	for customerId, expected := range map[string]string{`"customerId":"54321",`: "54321", "": ""} {
+		meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || r.URL.Path != "/v1/private-networks/100" {
+				t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+			}
+			privateNetwork := strings.Replace(testPrivateNetworkJson(100, "audited"), `"customerId":"54321",`, customerId, 1)
+			writeJson(w, http.StatusOK, `{"data":[`+privateNetwork+`],"_links":{"self":"/"}}`)
+		}))
+
+		d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{})
+		d.SetId("100")
+
+		if diags := resourcePrivateNetworkRead(context.Background(), d, meta); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if createdBy := d.Get("created_by").(string); createdBy != expected {
+			t.Fatalf("expected created_by to be %q, got %q", expected, createdBy)
+		}
+	}
+}
+
+func TestPrivateNetworkCreateAppliesDefaultDescription(t *testing.T) {
+	for configured, expected := range map[string]string{"": "managed-by-terraform", "database nodes": "database nodes"} {
+		var createRequest struct {
+			Description string `json:"description"`
+		}
+		meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks":
+				if err := json.NewDecoder(r.Body).Decode(&createRequest); err != nil {
+					t.Fatal(err)
+				}
+				writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "described")+`],"_links":{"self":"/"}}`)
+			case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+				writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "described")+`],"_links":{"self":"/"}}`)
+			default:
+				t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+			}
+		}))
+		meta.defaultDescription = "managed-by-terraform"
+
+		d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+			"name":        "described",
+			"description": configured,
+		})
+
+		if diags := resourcePrivateNetworkCreate(context.Background(), d, meta); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if createRequest.Description != expected {
+			t.Fatalf("expected the description %q for the configured description %q, got %q", expected, configured, createRequest.Description)
+		}
+	}
+}
+
+func TestPrivateNetworkRefreshRepairsMissingAddOn(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "mismatched",
+				testPrivateNetworkInstanceJson(10, "ok"),
+				testPrivateNetworkInstanceJson(20, "ok"))+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/10":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(10)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/20":
+			// the add-on of instance 20 was removed out-of-band
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceJson(20, "running")+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	for _, checkAddOns := range []bool{false, true} {
+		d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+			"name":         "mismatched",
+			"instance_ids": []interface{}{10, 20},
+			"check_addons": checkAddOns,
+		})
+		d.SetId("100")
+
+		diags := resourcePrivateNetworkRefresh(context.Background(), d, meta)
+		if diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+
+		instanceIds := d.Get("instance_ids").(*schema.Set)
+		if !checkAddOns {
+			if len(diags) != 0 || instanceIds.Len() != 2 {
+				t.Fatalf("expected no check without check_addons, got %v and %v", diags, instanceIds.List())
+			}
+			continue
+		}
+		if len(diags) != 1 || diags[0].Severity != diag.Warning || !strings.Contains(diags[0].Detail, "[20]") {
+			t.Fatalf("expected a single warning about instance 20, got %v", diags)
+		}
+		if instanceIds.Len() != 1 || !instanceIds.Contains(10) {
+			t.Fatalf("expected instance 20 to be dropped from the state to repair it, got %v", instanceIds.List())
+		}
+
+		// refreshing again must not report the dropped instance as added
+		diags = resourcePrivateNetworkRefresh(context.Background(), d, meta)
+		if len(diags) != 1 || diags[0].Summary != "Instances of the Private Network without private networking add-on" {
+			t.Fatalf("expected only the warning about the missing add-on again, got %v", diags)
+		}
+	}
+}
+
+func TestPrivateNetworkRefreshKeepsUnreadableInstance(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "deleting",
+				testPrivateNetworkInstanceJson(10, "ok"),
+				testPrivateNetworkInstanceJson(20, "ok"))+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/10":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(10)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/20":
+			// instance 20 is deleted, but still listed as member
+			writeJson(w, http.StatusNotFound, `{"statusCode":404,"message":"Entry Instances not found"}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":         "deleting",
+		"instance_ids": []interface{}{10, 20},
+		"check_addons": true,
+	})
+	d.SetId("100")
+
+	diags := resourcePrivateNetworkRefresh(context.Background(), d, meta)
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if len(diags) != 1 || !strings.Contains(diags[0].Summary, "instance 20") {
+		t.Fatalf("expected a single warning about instance 20, got %v", diags)
+	}
+	if instanceIds := d.Get("instance_ids").(*schema.Set); instanceIds.Len() != 2 {
+		t.Fatalf("expected instance 20 to be kept without its details, got %v", instanceIds.List())
+	}
+	instances := d.Get("instances").([]interface{})
+	if region := instances[1].(map[string]interface{})["region"]; region != "" {
+		t.Fatalf("expected no region of instance 20, got %v", region)
+	}
+	if region := instances[0].(map[string]interface{})["region"]; region != "EU" {
+		t.Fatalf("expected the region of instance 10, got %v", region)
+	}
+}
+
+func TestPrivateNetworkUpdateKeepsRemainingInstancesAssigned(t *testing.T) {
+	// instance 10 is a member of both networks and stays in both
+	members := map[int][]int{100: {10, 20}, 200: {10}}
+	var mutex sync.Mutex
+	changes := []string{}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var privateNetworkId, instanceId int
+		switch {
+		case r.Method == http.MethodGet && sscanfPath(r.URL.Path, "/v1/private-networks/%d", &privateNetworkId):
+			instances := []string{}
+			for _, instanceId := range members[privateNetworkId] {
+				instances = append(instances, testPrivateNetworkInstanceJson(instanceId, "ok"))
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(privateNetworkId, "shared", instances...)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && sscanfPath(r.URL.Path, "/v1/compute/instances/%d/upgrade", &instanceId):
+			writeJson(w, http.StatusOK, `{"data":[{"tenantId":"DE","customerId":"54321","instanceId":`+strconv.Itoa(instanceId)+`}],"_links":{"self":"/"}}`)
+		case sscanfPath(r.URL.Path, "/v1/private-networks/%d/instances/%d", &privateNetworkId, &instanceId):
+			mutex.Lock()
+			changes = append(changes, fmt.Sprintf("%s %d/%d", r.Method, privateNetworkId, instanceId))
+			mutex.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":         "shared",
+		"instance_ids": []interface{}{10, 20},
+	})
+	d.SetId("100")
+	state := d.State()
+	diff, err := resourcePrivateNetwork().Diff(
+		context.Background(),
+		state,
+		terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":         "shared",
+			"instance_ids": []interface{}{10, 30},
+		}),
+		meta,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err = schema.InternalMap(resourcePrivateNetwork().Schema).Data(state, diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diags := handleInstanceChanges(context.Background(), nil, d, meta, 100); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	expected := []string{"DELETE 100/20", "POST 100/30"}
+	if fmt.Sprint(changes) != fmt.Sprint(expected) {
+		t.Fatalf("expected only the membership changes %v, got %v", expected, changes)
+	}
+}
+
+func TestCidrHostRange(t *testing.T) {
+	for _, c := range []struct {
+		cidr      string
+		firstIp   string
+		lastIp    string
+		hostCount int
+	}{
+		{"10.0.0.0/22", "10.0.0.1", "10.0.3.254", 1022},
+		{"192.168.4.0/24", "192.168.4.1", "192.168.4.254", 254},
+		{"10.0.3.255/22", "10.0.0.1", "10.0.3.254", 1022},
+		{"172.16.0.0/30", "172.16.0.1", "172.16.0.2", 2},
+		{"172.16.0.0/31", "172.16.0.0", "172.16.0.1", 2},
+		{"172.16.0.7/32", "172.16.0.7", "172.16.0.7", 1},
+		{"0.0.0.0/0", "0.0.0.1", "255.255.255.254", 4294967294},
+		{"fd00::/64", "", "", 0},
+		{"", "", "", 0},
+	} {
+		firstIp, lastIp, hostCount := cidrHostRange(c.cidr)
+		if firstIp != c.firstIp || lastIp != c.lastIp || hostCount != c.hostCount {
+			t.Errorf("expected %q to range from %q to %q with %d hosts, got %q to %q with %d hosts",
+				c.cidr, c.firstIp, c.lastIp, c.hostCount, firstIp, lastIp, hostCount)
+		}
+	}
+}
+
+func TestPrivateNetworkUpdateReassignsReplacedInstance(t *testing.T) {
+	members := []int{10, 20}
+	var mutex sync.Mutex
+	changes := []string{}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var instanceId int
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			instances := []string{}
+			for _, instanceId := range members {
+				instances = append(instances, testPrivateNetworkInstanceJson(instanceId, "ok"))
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "replaced", instances...)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && sscanfPath(r.URL.Path, "/v1/compute/instances/%d/upgrade", &instanceId):
+			writeJson(w, http.StatusOK, `{"data":[{"tenantId":"DE","customerId":"54321","instanceId":`+strconv.Itoa(instanceId)+`}],"_links":{"self":"/"}}`)
+		case sscanfPath(r.URL.Path, "/v1/private-networks/100/instances/%d", &instanceId):
+			mutex.Lock()
+			changes = append(changes, fmt.Sprintf("%s %d", r.Method, instanceId))
+			mutex.Unlock()
+			// the replaced instance 10 was destroyed before the update
+			if r.Method == http.MethodDelete && instanceId == 10 {
+				writeJson(w, http.StatusNotFound, `{"statusCode":404,"message":"Entry Instance not found"}`)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":         "replaced",
+		"instance_ids": []interface{}{10, 20},
+	})
+	d.SetId("100")
+	state := d.State()
+	diff, err := resourcePrivateNetwork().Diff(
+		context.Background(),
+		state,
+		terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":         "replaced",
+			"instance_ids": []interface{}{11, 20},
+		}),
+		meta,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err = schema.InternalMap(resourcePrivateNetwork().Schema).Data(state, diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diags := handleInstanceChanges(context.Background(), nil, d, meta, 100); diags.HasError() {
+		t.Fatalf("expected the gone instance to count as unassigned, got %v", diags)
+	}
+
+	expected := []string{"DELETE 10", "POST 11"}
+	if fmt.Sprint(changes) != fmt.Sprint(expected) {
+		t.Fatalf("expected the old instance to be detached before the new one is attached, got %v", changes)
+	}
+}
+
+func TestPrivateNetworkUpdateKeepsInstanceChangesIfPatchFails(t *testing.T) {
+	members := []int{10}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var instanceId int
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			instances := []string{}
+			for _, instanceId := range members {
+				instances = append(instances, testPrivateNetworkInstanceJson(instanceId, "ok"))
+			}
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "old", instances...)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && sscanfPath(r.URL.Path, "/v1/compute/instances/%d", &instanceId):
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(instanceId)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && sscanfPath(r.URL.Path, "/v1/compute/instances/%d/upgrade", &instanceId):
+			writeJson(w, http.StatusConflict, `{"statusCode":409,"message":"Add-on already present"}`)
+		case r.Method == http.MethodPost && sscanfPath(r.URL.Path, "/v1/private-networks/100/instances/%d", &instanceId):
+			members = append(members, instanceId)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPatch && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusInternalServerError, `{"statusCode":500,"message":"Internal Server Error"}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":         "old",
+		"instance_ids": []interface{}{10},
+	})
+	d.SetId("100")
+	state := d.State()
+	diff, err := resourcePrivateNetwork().Diff(
+		context.Background(),
+		state,
+		terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":         "new",
+			"instance_ids": []interface{}{10, 20},
+		}),
+		meta,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err = schema.InternalMap(resourcePrivateNetwork().Schema).Data(state, diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diags := resourcePrivateNetworkUpdate(context.Background(), d, meta); !diags.HasError() {
+		t.Fatal("expected the failed patch to be reported")
+	}
+	if instanceIds := d.Get("instance_ids").(*schema.Set); instanceIds.Len() != 2 || !instanceIds.Contains(20) {
+		t.Fatalf("expected the assigned instance 20 to be kept in the state, got %v", instanceIds.List())
+	}
+	if name := d.Get("name").(string); name != "old" {
+		t.Fatalf("expected the name of the failed patch not to be stored, got %q", name)
+	}
+}
+
+func TestPrivateNetworkCreateWithoutInstancesHasEmptyLists(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks":
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "fresh")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "fresh")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/tags":
+			writeJson(w, http.StatusOK, listBody("", 0))
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":        "fresh",
+		"allow_empty": true,
+	})
+
+	if diags := resourcePrivateNetworkCreate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	// an attribute which was never set has no count in the state
+	attributes := d.State().Attributes
+	for _, key := range []string{"instance_ids.#", "instances.#", "tags.#"} {
+		if count, ok := attributes[key]; !ok || count != "0" {
+			t.Fatalf("expected %s to be an empty list, got %q (set: %v)", key, count, ok)
+		}
+	}
+}
+
+func TestPrivateNetworkUpdateSkipsInstancesIfAssignedOnCreateOnly(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("the instances must not be reconciled, got %s %s", r.Method, r.URL)
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":                  "managed-elsewhere",
+		"assign_on_create_only": true,
+		"instance_ids":          []interface{}{10, 20},
+	})
+	d.SetId("100")
+	state := d.State()
+	diff, err := resourcePrivateNetwork().Diff(
+		context.Background(),
+		state,
+		terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":                  "managed-elsewhere",
+			"assign_on_create_only": true,
+			"instance_ids":          []interface{}{10, 30},
+		}),
+		meta,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err = schema.InternalMap(resourcePrivateNetwork().Schema).Data(state, diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diags := resourcePrivateNetworkUpdate(context.Background(), d, meta)
+	if diags.HasError() || len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Fatalf("expected a single warning, got %v", diags)
+	}
+	if instanceIds := d.Get("instance_ids").(*schema.Set); instanceIds.Len() != 2 || !instanceIds.Contains(20) {
+		t.Fatalf("expected the state to keep the assigned instances, got %v", instanceIds.List())
+	}
+}
+
+func TestPrivateNetworkIgnoreInstancesKeepsConfiguredInstances(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var instanceId int
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			// instance 20 was replaced by instance 30 outside of Terraform
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "managed-elsewhere",
+				testPrivateNetworkInstanceJson(10, "ok"),
+				testPrivateNetworkInstanceJson(30, "ok"))+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && sscanfPath(r.URL.Path, "/v1/compute/instances/%d", &instanceId):
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(instanceId)+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/tags"):
+			writeJson(w, http.StatusOK, listBody("", 0))
+		default:
+			t.Fatalf("the instances must not be reconciled, got %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":             "managed-elsewhere",
+		"ignore_instances": true,
+		"instance_ids":     []interface{}{10, 20},
+	})
+	d.SetId("100")
+
+	if diags := resourcePrivateNetworkRefresh(context.Background(), d, meta); len(diags) != 0 {
+		t.Fatalf("expected no diagnostics, got %v", diags)
+	}
+	if instanceIds := d.Get("instance_ids").(*schema.Set); instanceIds.Len() != 2 || !instanceIds.Contains(20) {
+		t.Fatalf("expected instance_ids to keep the configured instances, got %v", instanceIds.List())
+	}
+	if instances := d.Get("instances").([]interface{}); len(instances) != 2 || instances[1].(map[string]interface{})["instance_id"] != 30 {
+		t.Fatalf("expected instances to list the actual instances, got %v", instances)
+	}
+
+	state := d.State()
+	diff, err := resourcePrivateNetwork().Diff(
+		context.Background(),
+		state,
+		terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":             "managed-elsewhere",
+			"ignore_instances": true,
+			"instance_ids":     []interface{}{10, 40},
+		}),
+		meta,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err = schema.InternalMap(resourcePrivateNetwork().Schema).Data(state, diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diags := resourcePrivateNetworkUpdate(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if instanceIds := d.Get("instance_ids").(*schema.Set); instanceIds.Len() != 2 || !instanceIds.Contains(40) {
+		t.Fatalf("expected the configured instance_ids in the state, got %v", instanceIds.List())
+	}
+}
+
+func TestPrivateNetworkingAddOnRequestMatchesSpec(t *testing.T) {
+	upgradeInstance, err := privateNetworkingAddOnRequest(privateNetworkingAddOnSpecVersion)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	payload, err := json.Marshal(upgradeInstance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(payload) != `{"privateNetworking":{}}` {
+		t.Fatalf("expected the add-on to be ordered with an empty object, got %s", payload)
+	}
+
+	if _, err := privateNetworkingAddOnRequest(privateNetworkingAddOnSpecVersion + 1); err == nil {
+		t.Fatal("expected an unknown spec version to be rejected")
+	}
+}
+
+func TestPrivateNetworkAdoptMatchingNetworkChangesNothing(t *testing.T) {
+	existing := testPrivateNetworkJson(100, "database", testPrivateNetworkInstanceJson(10, "ok"))
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Fatalf("adopting a matching Private Network must not change it, got %s %s", r.Method, r.URL)
+		}
+		switch r.URL.Path {
+		case "/v1/private-networks":
+			writeJson(w, http.StatusOK, listBody(existing, 1))
+		case "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+existing+`],"_links":{"self":"/"}}`)
+		case "/v1/compute/instances/10":
+			writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(10)+`],"_links":{"self":"/"}}`)
+		case "/v1/tags":
+			writeJson(w, http.StatusOK, listBody(testTagJson(7, "production"), 1))
+		case "/v1/tags/7/assignments":
+			writeJson(w, http.StatusOK, listBody(testAssignmentJson(7, privateNetworkTagResourceType, "100"), 1))
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":           "database",
+		"adopt_existing": true,
+		"instance_ids":   []interface{}{10},
+		"tags":           []interface{}{7},
+	})
+
+	if diags := resourcePrivateNetworkCreate(context.Background(), d, meta); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if d.Id() != "100" {
+		t.Fatalf("expected the existing Private Network 100 to be adopted, got %q", d.Id())
+	}
+	if tags := d.Get("tags").(*schema.Set); tags.Len() != 1 || !tags.Contains(7) {
+		t.Fatalf("expected tag 7 in the state, got %v", tags.List())
+	}
+}
+
+func TestPrivateNetworkImportKeepsRegionWithoutDiff(t *testing.T) {
+	usNetwork := strings.Replace(testPrivateNetworkJson(100, "us-network"), `"region":"EU"`, `"region":"US-central"`, 1)
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/private-networks/100" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		writeJson(w, http.StatusOK, `{"data":[`+usNetwork+`],"_links":{"self":"/"}}`)
+	}))
+
+	d := resourcePrivateNetwork().Data(nil)
+	d.SetId("100")
+	if diags := resourcePrivateNetworkRefresh(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	diff, err := resourcePrivateNetwork().Diff(
+		context.Background(),
+		d.State(),
+		terraform.NewResourceConfigRaw(map[string]interface{}{"name": "us-network"}),
+		meta,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil && diff.Attributes["region"] != nil {
+		t.Fatalf("expected no region diff after importing a US network, got %#v", diff.Attributes["region"])
+	}
+}
+
+func TestPrivateNetworkCreateDescribesFailedInstance(t *testing.T) {
+	failedInstance := strings.Replace(testInstanceJson(200, "error"), `"errorMessage":null`, `"errorMessage":"Installation failed"`, 1)
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks":
+			writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "failing")+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/compute/instances/200/upgrade":
+			writeJson(w, http.StatusBadRequest, `{"statusCode":400,"message":"Instance is in an error state"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/200":
+			writeJson(w, http.StatusOK, `{"data":[`+failedInstance+`],"_links":{"self":"/"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+			writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "failing")+`],"_links":{"self":"/"}}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	meta.maxRetries = 0
+
+	d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+		"name":         "failing",
+		"instance_ids": []interface{}{200},
+	})
+
+	diags := resourcePrivateNetworkCreate(context.Background(), d, meta)
+	if !diags.HasError() {
+		t.Fatal("expected the failed instance to fail the create")
+	}
+	if diags[0].Summary != "Could not add instance 200 to the Private Network 100" {
+		t.Fatalf("expected the summary to name the instance, got %q", diags[0].Summary)
+	}
+	for _, expected := range []string{`status "error"`, `error message "Installation failed"`, "Instance is in an error state", "request id: "} {
+		if !strings.Contains(diags[0].Detail, expected) {
+			t.Fatalf("expected the detail to contain %q, got %q", expected, diags[0].Detail)
+		}
+	}
+}
+
+func TestPrivateNetworkAddOnConflictAsError(t *testing.T) {
+	for _, treatConflictAsError := range []bool{false, true} {
+		assigned := false
+		meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == "/v1/compute/instances/200/upgrade":
+				writeJson(w, http.StatusConflict, `{"statusCode":409,"message":"Addon already exists"}`)
+			case r.Method == http.MethodGet && r.URL.Path == "/v1/compute/instances/200":
+				writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(200)+`],"_links":{"self":"/"}}`)
+			case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+				writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "strict")+`],"_links":{"self":"/"}}`)
+			case r.Method == http.MethodPost && r.URL.Path == "/v1/private-networks/100/instances/200":
+				assigned = true
+				writeJson(w, http.StatusCreated, `{"data":[`+testPrivateNetworkJson(100, "strict")+`],"_links":{"self":"/"}}`)
+			default:
+				t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+			}
+		}))
+		meta.treatConflictAsError = treatConflictAsError
+
+		diags := addInstancesToPrivateNetwork(context.Background(), nil, meta, 100, []interface{}{200})
+
+		if treatConflictAsError {
+			if !diags.HasError() || !strings.Contains(diags[0].Detail, "status code: 409") {
+				t.Fatalf("expected the conflict to fail the assignment, got %v", diags)
+			}
+			if assigned {
+				t.Fatal("expected the instance not to be assigned after the conflict")
+			}
+			continue
+		}
+		if diags.HasError() || !assigned {
+			t.Fatalf("expected the conflict to be taken as success, got %v", diags)
+		}
+	}
+}
+
+func TestPrivateNetworkRejectsMoreInstancesThanTheCidrRange(t *testing.T) {
+	state := &terraform.InstanceState{
+		ID: "100",
+		Attributes: map[string]string{
+			"id":             "100",
+			"name":           "small",
+			"cidr":           "10.0.0.0/30",
+			"instance_ids.#": "1",
+			fmt.Sprintf("instance_ids.%d", schema.HashInt(200)): "200",
+		},
+	}
+
+	_, err := resourcePrivateNetwork().Diff(
+		context.Background(),
+		state,
+		terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":         "small",
+			"instance_ids": []interface{}{200, 201},
+		}),
+		nil,
+	)
+	if err == nil || !strings.Contains(err.Error(), "has addresses for 1 instances besides its gateway, but instance_ids contains 2 instances") {
+		t.Fatalf("expected too many instances for the /30 range to be rejected, got %v", err)
+	}
+
+	_, err = resourcePrivateNetwork().Diff(
+		context.Background(),
+		state,
+		terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":         "small",
+			"instance_ids": []interface{}{201},
+		}),
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("expected an instance fitting into the /30 range to be accepted, got %v", err)
+	}
+}
+
+func TestPrivateNetworkUpdateUsesPatchResponse(t *testing.T) {
+	for _, c := range []struct {
+		patchBody string
+		reads     int
+	}{
+		{`{"data":[` + testPrivateNetworkJson(100, "renamed", testPrivateNetworkInstanceJson(10, "ok")) + `],"_links":{"self":"/"}}`, 0},
+		{`{"data":[],"_links":{"self":"/"}}`, 1},
+	} {
+		reads := 0
+		meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var instanceId int
+			switch {
+			case r.Method == http.MethodPatch && r.URL.Path == "/v1/private-networks/100":
+				writeJson(w, http.StatusOK, c.patchBody)
+			case r.Method == http.MethodGet && r.URL.Path == "/v1/private-networks/100":
+				reads++
+				writeJson(w, http.StatusOK, `{"data":[`+testPrivateNetworkJson(100, "renamed", testPrivateNetworkInstanceJson(10, "ok"))+`],"_links":{"self":"/"}}`)
+			case r.Method == http.MethodGet && sscanfPath(r.URL.Path, "/v1/compute/instances/%d", &instanceId):
+				writeJson(w, http.StatusOK, `{"data":[`+testInstanceWithPrivateNetworkingJson(instanceId)+`],"_links":{"self":"/"}}`)
+			default:
+				t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+			}
+		}))
+
+		d := schema.TestResourceDataRaw(t, resourcePrivateNetwork().Schema, map[string]interface{}{
+			"name":                     "old",
+			"instance_ids":             []interface{}{10},
+			"include_instance_details": true,
+		})
+		d.SetId("100")
+		state := d.State()
+		diff, err := resourcePrivateNetwork().Diff(
+			context.Background(),
+			state,
+			terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name":                     "renamed",
+				"instance_ids":             []interface{}{10},
+				"include_instance_details": true,
+			}),
+			meta,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err = schema.InternalMap(resourcePrivateNetwork().Schema).Data(state, diff)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diags := resourcePrivateNetworkUpdate(context.Background(), d, meta); diags.HasError() {
+			t.Fatalf("unexpected error: %v", diags)
+		}
+		if reads != c.reads {
+			t.Fatalf("expected %d reads after the patch returned %s, got %d", c.reads, c.patchBody, reads)
+		}
+		if name := d.Get("name").(string); name != "renamed" {
+			t.Fatalf("expected the updated name in the state, got %q", name)
+		}
+		if instances := d.Get("instances").([]interface{}); len(instances) != 1 || instances[0].(map[string]interface{})["os_type"] != "Linux" {
+			t.Fatalf("expected the details of the instances in the state, got %v", instances)
+		}
+	}
+}
@@ -3,11 +3,13 @@ package contabo
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
 	"testing"
 
-	"contabo.com/openapi"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	uuid "github.com/satori/go.uuid"
 )
@@ -29,7 +31,7 @@ func TestAccContaboSecretBasic(t *testing.T) {
 }
 
 func testAccCheckSecretDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*openapi.APIClient)
+	client := testAccProvider.Meta().(*providerMeta).client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "contabo_secret" {
@@ -83,3 +85,111 @@ func testCheckContaboSecretExists(n string) resource.TestCheckFunc {
 		return nil
 	}
 }
+
+func testSecretJson(secretId int, name string) string {
+	return fmt.Sprintf(
+		`{"tenantId":"DE","customerId":"54321","secretId":%d,"name":"%s","type":"ssh","value":"","createdAt":"2022-01-01T00:00:00Z","updatedAt":"2022-01-01T00:00:00Z"}`,
+		secretId, name)
+}
+
+func TestSecretImportByName(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secrets" || r.URL.Query().Get("name") != "deploy" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		// the API filter matches partially, "deploy-old" must be ignored
+		writeJson(w, http.StatusOK, listBody(testSecretJson(42, "deploy")+","+testSecretJson(43, "deploy-old"), 2))
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceSecret().Schema, map[string]interface{}{})
+	d.SetId("deploy")
+
+	imported, err := resourceSecretImport(context.Background(), d, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(imported) != 1 || imported[0].Id() != "42" {
+		t.Fatalf("expected secret 42 to be imported, got %v", imported[0].Id())
+	}
+}
+
+func TestSecretImportById(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("import by id must not call the API, got %s %s", r.Method, r.URL)
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceSecret().Schema, map[string]interface{}{})
+	d.SetId("42")
+
+	imported, err := resourceSecretImport(context.Background(), d, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(imported) != 1 || imported[0].Id() != "42" {
+		t.Fatalf("expected secret 42 to be imported, got %v", imported[0].Id())
+	}
+}
+
+func TestSecretImportByAmbiguousName(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusOK, listBody(testSecretJson(42, "deploy")+","+testSecretJson(44, "deploy"), 2))
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceSecret().Schema, map[string]interface{}{})
+	d.SetId("deploy")
+
+	if _, err := resourceSecretImport(context.Background(), d, meta); err == nil {
+		t.Fatal("expected an error for an ambiguous secret name")
+	}
+}
+
+func TestSecretImportByNameOnLaterPage(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		secretJson := testSecretJson(43, "deploy-old")
+		if page == "2" {
+			secretJson = testSecretJson(42, "deploy")
+		}
+		writeJson(w, http.StatusOK, fmt.Sprintf(
+			`{"_pagination":{"size":1,"totalElements":2,"totalPages":2,"page":%s},"data":[%s],"_links":{"self":"/"}}`,
+			page, secretJson))
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceSecret().Schema, map[string]interface{}{})
+	d.SetId("deploy")
+
+	imported, err := resourceSecretImport(context.Background(), d, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(imported) != 1 || imported[0].Id() != "42" {
+		t.Fatalf("expected secret 42 of the second page to be imported, got %v", imported[0].Id())
+	}
+}
+
+func TestSecretSshKeyValidation(t *testing.T) {
+	diff := func(secretType string, value string) error {
+		_, err := resourceSecret().Diff(
+			context.Background(),
+			nil,
+			terraform.NewResourceConfigRaw(map[string]interface{}{
+				"name":  "deploy",
+				"type":  secretType,
+				"value": value,
+			}),
+			nil,
+		)
+		return err
+	}
+
+	if err := diff("ssh", "ssh-ed25519 bogus"); err == nil || !strings.Contains(err.Error(), "no valid SSH public key") {
+		t.Fatalf("expected the bogus key to be rejected, got %v", err)
+	}
+	validKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIABTMqVRvrGzxjbdXjKDBYiMW+Gplj5Kc+kffWPcTNHq test@example"
+	if err := diff("ssh", validKey); err != nil {
+		t.Fatalf("expected the ed25519 key to be accepted, got %v", err)
+	}
+	if err := diff("password", "ssh-ed25519 bogus"); err != nil {
+		t.Fatalf("expected passwords not to be validated as keys, got %v", err)
+	}
+}
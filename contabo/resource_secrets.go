@@ -2,6 +2,7 @@ package contabo
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -9,8 +10,12 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	uuid "github.com/satori/go.uuid"
+	"golang.org/x/crypto/ssh"
 )
 
+// secretListPageSize is the page size used while looking up secrets by name.
+const secretListPageSize = 100
+
 func resourceSecret() *schema.Resource {
 	return &schema.Resource{
 		Description:   "The Secret Management API allows you to store and manage your passwords and ssh-keys. Usage of the Secret Management API is purely optional. As a convenience feature e.g. it allows you to reuse SSH-keys easily.",
@@ -18,10 +23,13 @@ func resourceSecret() *schema.Resource {
 		ReadContext:   resourceSecretRead,
 		UpdateContext: resourceSecretUpdate,
 		DeleteContext: resourceSecretDelete,
+		CustomizeDiff: validateSecretSshKey,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: resourceSecretImport,
 		},
 		Schema: map[string]*schema.Schema{
+			"_debug_last_response":  debugLastResponseSchema(),
+			"last_read_duration_ms": debugLastReadDurationSchema(),
 			"created_at": &schema.Schema{
 				Type:        schema.TypeString,
 				Optional:    true,
@@ -58,13 +66,29 @@ func resourceSecret() *schema.Resource {
 	}
 }
 
+// validateSecretSshKey rejects values of ssh secrets which are no SSH public
+// key at plan time, instead of failing the apply with a server error. It is a
+// CustomizeDiff as the validation of value depends on type.
+func validateSecretSshKey(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("type") || !d.NewValueKnown("value") || d.Get("type").(string) != "ssh" {
+		return nil
+	}
+
+	if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(d.Get("value").(string))); err != nil {
+		return fmt.Errorf(
+			"the value of the ssh secret %q is no valid SSH public key, e.g. `ssh-ed25519 AAAA... user@host`: %v",
+			d.Get("name").(string), err)
+	}
+	return nil
+}
+
 func resourceSecretCreate(
 	ctx context.Context,
 	d *schema.ResourceData,
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	secretName := d.Get("name").(string)
 	secretValue := d.Get("value").(string)
@@ -76,7 +100,7 @@ func resourceSecretCreate(
 	createSecretRequest.Type = secretType
 
 	res, httpResp, err := client.SecretsApi.
-		CreateSecret(context.Background()).
+		CreateSecret(ctx).
 		XRequestId(uuid.NewV4().String()).
 		CreateSecretRequest(*createSecretRequest).
 		Execute()
@@ -102,7 +126,7 @@ func resourceSecretRead(
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	secretId, err := strconv.ParseInt(d.Id(), 10, 64)
 
@@ -110,6 +134,7 @@ func resourceSecretRead(
 		return diag.FromErr(err)
 	}
 
+	start := time.Now()
 	res, httpResp, err := client.SecretsApi.
 		RetrieveSecret(ctx, secretId).
 		XRequestId(uuid.NewV4().String()).
@@ -126,7 +151,9 @@ func resourceSecretRead(
 		})
 	}
 
-	return AddSecretToData(res.Data[0], d, diags)
+	diags = AddSecretToData(res.Data[0], d, diags)
+	diags = AddDebugLastResponseToData(m, httpResp, d, diags)
+	return AddDebugReadDurationToData(m, start, d, diags)
 }
 
 func resourceSecretUpdate(
@@ -135,7 +162,7 @@ func resourceSecretUpdate(
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	secretId, err := strconv.ParseInt(d.Id(), 10, 64)
 
@@ -160,7 +187,7 @@ func resourceSecretUpdate(
 
 	if anyChange {
 		_, httpResp, err := client.SecretsApi.
-			UpdateSecret(context.Background(), secretId).
+			UpdateSecret(ctx, secretId).
 			XRequestId(uuid.NewV4().String()).
 			UpdateSecretRequest(*updateSecretRequest).
 			Execute()
@@ -182,7 +209,7 @@ func resourceSecretDelete(
 	m interface{},
 ) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	secretId, err := strconv.ParseInt(d.Id(), 10, 64)
 
@@ -228,3 +255,63 @@ func AddSecretToData(
 	}
 	return diags
 }
+
+// resourceSecretImport accepts either the numeric secret id or the name of
+// the secret. Names are resolved via the secrets list and must be unique.
+func resourceSecretImport(
+	ctx context.Context,
+	d *schema.ResourceData,
+	m interface{},
+) ([]*schema.ResourceData, error) {
+	client := m.(*providerMeta).client
+
+	importId := d.Id()
+	if _, err := strconv.ParseInt(importId, 10, 64); err == nil {
+		return []*schema.ResourceData{d}, nil
+	}
+
+	// the name filter of the API also matches partially, only exact matches count
+	var matches []openapi.SecretResponse
+	err := paginate(secretListPageSize, func(page int64) (int, int64, error) {
+		res, httpResp, err := client.SecretsApi.
+			RetrieveSecretList(ctx).
+			XRequestId(uuid.NewV4().String()).
+			Page(page).
+			Size(secretListPageSize).
+			Name(importId).
+			Execute()
+
+		if err != nil {
+			if httpResp != nil {
+				return 0, 0, fmt.Errorf("could not list secrets with name %q: %s", importId, httpResp.Status)
+			}
+			return 0, 0, fmt.Errorf("could not list secrets with name %q: %v", importId, err)
+		}
+
+		for _, secret := range res.Data {
+			if secret.Name == importId {
+				matches = append(matches, secret)
+			}
+		}
+		return len(res.Data), int64(res.Pagination.TotalPages), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no secret with name %q found", importId)
+	case 1:
+		d.SetId(strconv.Itoa(int(matches[0].SecretId)))
+		return []*schema.ResourceData{d}, nil
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, secret := range matches {
+			ids = append(ids, strconv.Itoa(int(secret.SecretId)))
+		}
+		return nil, fmt.Errorf(
+			"secret name %q is ambiguous, it matches the secrets %v. Please import by id instead",
+			importId, ids)
+	}
+}
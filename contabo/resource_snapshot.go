@@ -2,6 +2,9 @@ package contabo
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"contabo.com/openapi"
@@ -17,7 +20,7 @@ func resourceSnapshot() *schema.Resource {
 		UpdateContext: resourceSnapshotUpdate,
 		DeleteContext: resourceSnapshotDelete,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: resourceSnapshotImport,
 		},
 		Schema: map[string]*schema.Schema{
 			"id": {
@@ -34,7 +37,8 @@ func resourceSnapshot() *schema.Resource {
 			"description": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				Description: "Description of this snapshot.",
+				Computed:    true,
+				Description: "Description of this snapshot. If not set, the `default_description` of the provider is used.",
 			},
 			"instance_id": {
 				Type:        schema.TypeInt,
@@ -68,12 +72,12 @@ func resourceSnapshot() *schema.Resource {
 
 func resourceSnapshotCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	createSnapshotRequest := openapi.NewCreateSnapshotRequestWithDefaults()
 
 	name := d.Get("name").(string)
-	description := d.Get("description").(string)
+	description := descriptionOrDefault(d, m)
 	instanceId := d.Get("instance_id").(int)
 	if name != "" {
 		createSnapshotRequest.Name = name
@@ -102,7 +106,7 @@ func resourceSnapshotCreate(ctx context.Context, d *schema.ResourceData, m inter
 
 func resourceSnapshotRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	snapshotId := d.Id()
 
@@ -124,9 +128,62 @@ func resourceSnapshotRead(ctx context.Context, d *schema.ResourceData, m interfa
 	return AddSnapshotToData(res.Data[0], d, diags)
 }
 
+// resourceSnapshotImport imports a snapshot by the compound id
+// `instanceId/snapshotId`, as snapshots can only be retrieved through their
+// instance. The snapshot is retrieved to fail the import early if it does not
+// exist.
+func resourceSnapshotImport(
+	ctx context.Context,
+	d *schema.ResourceData,
+	m interface{},
+) ([]*schema.ResourceData, error) {
+	client := m.(*providerMeta).client
+
+	instanceId, snapshotId, err := parseSnapshotImportId(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	res, httpResp, err := client.SnapshotsApi.
+		RetrieveSnapshot(ctx, instanceId, snapshotId).
+		XRequestId(uuid.NewV4().String()).
+		Execute()
+	if err != nil {
+		return nil, NewApiError(httpResp, err)
+	} else if len(res.Data) != 1 {
+		return nil, fmt.Errorf("snapshot %s of instance %d not found", snapshotId, instanceId)
+	}
+
+	d.SetId(snapshotId)
+	if diags := AddSnapshotToData(res.Data[0], d, nil); diags.HasError() {
+		return nil, fmt.Errorf("%s", diags[0].Summary)
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+// parseSnapshotImportId splits the import id `instanceId/snapshotId`. The
+// instance id is numeric, the snapshot id is assigned by the API, e.g.
+// `snap1628603855`.
+func parseSnapshotImportId(importId string) (int64, string, error) {
+	parts := strings.Split(importId, "/")
+	if len(parts) != 2 {
+		return 0, "", fmt.Errorf("invalid snapshot import id %q, expected instanceId/snapshotId, e.g. 12345/snap1628603855", importId)
+	}
+
+	instanceId, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || instanceId <= 0 {
+		return 0, "", fmt.Errorf("invalid instance id %q in snapshot import id %q, expected a number", parts[0], importId)
+	}
+	snapshotId := parts[1]
+	if snapshotId == "" || strings.ContainsAny(snapshotId, " \t") {
+		return 0, "", fmt.Errorf("invalid snapshot id %q in snapshot import id %q", snapshotId, importId)
+	}
+	return instanceId, snapshotId, nil
+}
+
 func resourceSnapshotUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 	anyChange := false
 	patchSnapshotRequest := openapi.NewUpdateSnapshotRequest()
 
@@ -161,7 +218,7 @@ func resourceSnapshotUpdate(ctx context.Context, d *schema.ResourceData, m inter
 
 func resourceSnapshotDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
-	client := m.(*openapi.APIClient)
+	client := m.(*providerMeta).client
 
 	snapshotId := d.Id()
 
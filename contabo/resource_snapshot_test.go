@@ -3,10 +3,13 @@ package contabo
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
+	"testing"
 
-	"contabo.com/openapi"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	uuid "github.com/satori/go.uuid"
 )
@@ -30,7 +33,7 @@ var instanceId int64 = 10001001
 // }
 
 func testAccCheckInstanceSnapshotDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*openapi.APIClient)
+	client := testAccProvider.Meta().(*providerMeta).client
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "contabo_instance_snapshot" {
@@ -79,3 +82,57 @@ func testCheckContaboInstanceSnapshotExists(n string) resource.TestCheckFunc {
 		return nil
 	}
 }
+
+func TestSnapshotImportByCompoundId(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/compute/instances/12345/snapshots/snap1" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		writeJson(w, http.StatusOK, `{"data":[
+			{"tenantId":"DE","customerId":"54321","snapshotId":"snap1","name":"first","description":"",
+			"instanceId":12345,"createdDate":"2022-01-01T00:00:00Z","autoDeleteDate":"2022-02-01T00:00:00Z",
+			"imageId":"afecbb85-e2fc-46f0-9684-b46b1faf00bb","imageName":"ubuntu"}],"_links":{"self":"/"}}`)
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceSnapshot().Schema, map[string]interface{}{})
+	d.SetId("12345/snap1")
+
+	imported, err := resourceSnapshotImport(context.Background(), d, meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(imported) != 1 || imported[0].Id() != "snap1" {
+		t.Fatalf("expected snapshot snap1 to be imported, got %v", imported[0].Id())
+	}
+	if instanceId := imported[0].Get("instance_id").(int); instanceId != 12345 {
+		t.Fatalf("expected instance 12345, got %d", instanceId)
+	}
+}
+
+func TestSnapshotImportRejectsMalformedId(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("a malformed import id must not call the API, got %s %s", r.Method, r.URL)
+	}))
+
+	for _, importId := range []string{"snap1", "12345", "abc/snap1", "12345/", "1/2/3"} {
+		d := schema.TestResourceDataRaw(t, resourceSnapshot().Schema, map[string]interface{}{})
+		d.SetId(importId)
+
+		if _, err := resourceSnapshotImport(context.Background(), d, meta); err == nil || !strings.Contains(err.Error(), importId) {
+			t.Fatalf("expected an error naming the import id %q, got %v", importId, err)
+		}
+	}
+}
+
+func TestSnapshotImportOfMissingSnapshot(t *testing.T) {
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, http.StatusNotFound, `{"statusCode":404,"message":"Entry Snapshot not found by snapshotId snap9"}`)
+	}))
+
+	d := schema.TestResourceDataRaw(t, resourceSnapshot().Schema, map[string]interface{}{})
+	d.SetId("12345/snap9")
+
+	if _, err := resourceSnapshotImport(context.Background(), d, meta); err == nil {
+		t.Fatal("expected the import of a missing snapshot to fail")
+	}
+}
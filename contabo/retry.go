@@ -0,0 +1,77 @@
+package contabo
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"contabo.com/terraform-provider-contabo/client"
+)
+
+// defaultMaxRetries is the number of retries if the provider does not
+// configure max_retries.
+const defaultMaxRetries = 10
+
+// defaultMaxReadRetries and defaultMaxMutationRetries are the number of times
+// a single reading or mutating API call is repeated by the transport if the
+// provider does not configure max_read_retries or max_mutation_retries.
+const (
+	defaultMaxReadRetries     = 5
+	defaultMaxMutationRetries = 1
+)
+
+// retryDelay is the time between two attempts of a retried call.
+var retryDelay = time.Second
+
+// retryOnTransientError repeats the call as long as it fails transiently, see
+// isTransient. Other failures are returned right away so that e.g. a 404 can
+// be handled by the caller. The call is retried at most max_retries times.
+func retryOnTransientError(
+	ctx context.Context,
+	meta *providerMeta,
+	call func(ctx context.Context) (*http.Response, error),
+) (*http.Response, error) {
+	return retryWhile(ctx, meta.maxRetries, meta.isTransient, func(ctx context.Context, attempt int) (*http.Response, error) {
+		return call(ctx)
+	})
+}
+
+// isTransient reports whether a failed call might succeed when retried. If the
+// provider configures retry_status_codes, they replace the status codes of
+// ApiError.IsTransient. Calls without response are always transient.
+func (meta *providerMeta) isTransient(apiError *ApiError) bool {
+	if len(meta.retryStatusCodes) == 0 || apiError.StatusCode == 0 {
+		return apiError.IsTransient()
+	}
+	for _, statusCode := range meta.retryStatusCodes {
+		if apiError.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// retryWhile repeats the call as long as its failure is retryable, at most
+// maxRetries times. The call is passed its attempt number, starting at 1, and
+// a context to make its requests with, which the transport does not retry, so
+// that the retries of both do not multiply.
+func retryWhile(
+	ctx context.Context,
+	maxRetries int,
+	retryable func(*ApiError) bool,
+	call func(ctx context.Context, attempt int) (*http.Response, error),
+) (*http.Response, error) {
+	callCtx := client.WithoutRetries(ctx)
+	for retry := 0; ; retry++ {
+		httpResp, err := call(callCtx, retry+1)
+		if err == nil || retry >= maxRetries || !retryable(NewApiError(httpResp, err)) {
+			return httpResp, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return httpResp, err
+		case <-time.After(retryDelay):
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package contabo
+
+import (
+	"context"
+	"fmt"
+
+	"contabo.com/openapi"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	uuid "github.com/satori/go.uuid"
+)
+
+// resource types as used by the tag assignments of the API
+const (
+	instanceTagResourceType       = "instance"
+	privateNetworkTagResourceType = "private-network"
+)
+
+func tagsSchema(resourceName string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeSet,
+		Elem:        &schema.Schema{Type: schema.TypeInt},
+		Optional:    true,
+		Description: fmt.Sprintf("Ids of the tags which should be assigned to the %s.", resourceName),
+	}
+}
+
+// tagCreatedResource assigns the configured tags to a just created resource.
+// Failing assignments only warn, the resource itself exists and the missing
+// tags are retried on the next apply.
+func tagCreatedResource(
+	ctx context.Context,
+	d *schema.ResourceData,
+	client *openapi.APIClient,
+	resourceType string,
+) diag.Diagnostics {
+	diags := updateTags(ctx, d, client, resourceType)
+	for i := range diags {
+		diags[i].Severity = diag.Warning
+	}
+	return diags
+}
+
+// tagAdoptedResource assigns the configured tags to an adopted resource.
+// Configured tags which are assigned already are left alone, so that adopting
+// a matching resource does not change it. Other assigned tags are kept as
+// well. Like for created resources failing assignments only warn.
+func tagAdoptedResource(
+	ctx context.Context,
+	d *schema.ResourceData,
+	client *openapi.APIClient,
+	resourceType string,
+) diag.Diagnostics {
+	wanted := schema.NewSet(schema.HashInt, d.Get("tags").(*schema.Set).List())
+	if wanted.Len() == 0 {
+		return nil
+	}
+
+	assigned, err := readAssignedTags(ctx, client, resourceType, d.Id())
+	if err != nil {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Could not read the tags of the adopted resource",
+			Detail:   fmt.Sprintf("The tags of %s %s are assigned on the next apply: %s", resourceType, d.Id(), err),
+		}}
+	}
+	alreadyAssigned := schema.NewSet(schema.HashInt, nil)
+	for _, tagId := range wanted.List() {
+		if assigned.Contains(tagId) {
+			alreadyAssigned.Add(tagId)
+		}
+	}
+
+	tags, diags := reconcileTags(ctx, client, resourceType, d.Id(), alreadyAssigned, wanted)
+	for i := range diags {
+		diags[i].Severity = diag.Warning
+	}
+	if err := d.Set("tags", tags); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
+
+// updateTags applies the change of the tags of the resource and keeps the
+// actually assigned tags in the state.
+func updateTags(
+	ctx context.Context,
+	d *schema.ResourceData,
+	client *openapi.APIClient,
+	resourceType string,
+) diag.Diagnostics {
+	old, new := d.GetChange("tags")
+	assigned, diags := reconcileTags(
+		ctx, client, resourceType, d.Id(), old.(*schema.Set), new.(*schema.Set))
+
+	if err := d.Set("tags", assigned); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
+	return diags
+}
+
+// reconcileTags assigns and unassigns tags so that the resource ends up with
+// the wanted tags. It returns the tags which are actually assigned afterwards,
+// so that failed assignments are not persisted in the state.
+func reconcileTags(
+	ctx context.Context,
+	client *openapi.APIClient,
+	resourceType string,
+	resourceId string,
+	assigned *schema.Set,
+	wanted *schema.Set,
+) (*schema.Set, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	result := schema.NewSet(schema.HashInt, assigned.List())
+
+	for _, tagId := range assigned.Difference(wanted).List() {
+		httpResp, err := client.TagAssignmentsApi.
+			DeleteAssignment(ctx, int64(tagId.(int)), resourceType, resourceId).
+			XRequestId(uuid.NewV4().String()).
+			Execute()
+
+		if err != nil {
+			if apiError := NewApiError(httpResp, err); !apiError.IsNotFound() {
+				diags = appendTagError(diags, "unassign", tagId.(int), resourceType, resourceId, apiError)
+				continue
+			}
+		}
+		result.Remove(tagId)
+	}
+
+	for _, tagId := range wanted.Difference(assigned).List() {
+		_, httpResp, err := client.TagAssignmentsApi.
+			CreateAssignment(ctx, int64(tagId.(int)), resourceType, resourceId).
+			XRequestId(uuid.NewV4().String()).
+			Execute()
+
+		if err != nil {
+			if apiError := NewApiError(httpResp, err); !apiError.IsConflict() {
+				diags = appendTagError(diags, "assign", tagId.(int), resourceType, resourceId, apiError)
+				continue
+			}
+		}
+		result.Add(tagId)
+	}
+
+	return result, diags
+}
+
+func appendTagError(
+	diags diag.Diagnostics,
+	action string,
+	tagId int,
+	resourceType string,
+	resourceId string,
+	apiError *ApiError,
+) diag.Diagnostics {
+	return append(diags, diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  fmt.Sprintf("Could not %s tag %d", action, tagId),
+		Detail:   fmt.Sprintf("Could not %s tag %d of %s %s: %s", action, tagId, resourceType, resourceId, apiError.Error()),
+	})
+}
+
+// tagPageSize is the page size used while listing tags and their assignments.
+const tagPageSize = 100
+
+// readAssignedTags looks up the tags assigned to the resource. The API offers
+// no lookup by resource, so the assignments of every tag have to be listed.
+func readAssignedTags(
+	ctx context.Context,
+	client *openapi.APIClient,
+	resourceType string,
+	resourceId string,
+) (*schema.Set, error) {
+	assigned := schema.NewSet(schema.HashInt, nil)
+
+	err := paginate(tagPageSize, func(page int64) (int, int64, error) {
+		tags, httpResp, err := client.TagsApi.
+			RetrieveTagList(ctx).
+			XRequestId(uuid.NewV4().String()).
+			Page(page).
+			Size(tagPageSize).
+			Execute()
+		if err != nil {
+			return 0, 0, NewApiError(httpResp, err)
+		}
+
+		for _, tag := range tags.Data {
+			isAssigned, err := isTagAssigned(ctx, client, tag.TagId, resourceType, resourceId)
+			if err != nil {
+				return 0, 0, err
+			}
+			if isAssigned {
+				assigned.Add(int(tag.TagId))
+			}
+		}
+
+		return len(tags.Data), int64(tags.Pagination.TotalPages), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return assigned, nil
+}
+
+func isTagAssigned(
+	ctx context.Context,
+	client *openapi.APIClient,
+	tagId int64,
+	resourceType string,
+	resourceId string,
+) (bool, error) {
+	isAssigned := false
+
+	err := paginate(tagPageSize, func(page int64) (int, int64, error) {
+		assignments, httpResp, err := client.TagAssignmentsApi.
+			RetrieveAssignmentList(ctx, tagId).
+			XRequestId(uuid.NewV4().String()).
+			Page(page).
+			Size(tagPageSize).
+			ResourceType(resourceType).
+			Execute()
+		if err != nil {
+			return 0, 0, NewApiError(httpResp, err)
+		}
+
+		for _, assignment := range assignments.Data {
+			if assignment.ResourceId == resourceId {
+				isAssigned = true
+				return 0, 0, errStopPaging
+			}
+		}
+
+		return len(assignments.Data), int64(assignments.Pagination.TotalPages), nil
+	})
+	return isAssigned, err
+}
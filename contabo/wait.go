@@ -0,0 +1,42 @@
+package contabo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// defaultPollInterval and defaultPollTimeout are the time between two checks
+// of a wait and its timeout if the provider does not configure poll_interval
+// or poll_timeout.
+const (
+	defaultPollInterval = 5 * time.Second
+	defaultPollTimeout  = 30 * time.Minute
+)
+
+// waitTimeout returns the timeout of a wait of the resource. A timeout set in
+// the timeouts block of the resource wins over the poll_timeout of the
+// provider. As the SDK does not tell configured timeouts apart from the
+// defaults, a timeout equal to the resource default counts as not set.
+// Resources using it create without the context timeout of the SDK, e.g. via
+// CreateWithoutTimeout, as it would cut a longer poll_timeout short.
+func waitTimeout(d *schema.ResourceData, m interface{}, key string, resourceDefault time.Duration) time.Duration {
+	timeout := d.Timeout(key)
+	if pollTimeout := m.(*providerMeta).pollTimeout; pollTimeout > 0 && timeout == resourceDefault {
+		return pollTimeout
+	}
+	return timeout
+}
+
+// validateDuration checks that the value is a positive duration, e.g. `5s`.
+func validateDuration(value interface{}, key string) ([]string, []error) {
+	duration, err := time.ParseDuration(value.(string))
+	if err != nil {
+		return nil, []error{fmt.Errorf("%q must be a duration like 5s or 30m, got %q", key, value)}
+	}
+	if duration <= 0 {
+		return nil, []error{fmt.Errorf("%q must be positive, got %q", key, value)}
+	}
+	return nil, nil
+}
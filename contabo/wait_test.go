@@ -0,0 +1,65 @@
+package contabo
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestWaitUsesConfiguredPollInterval(t *testing.T) {
+	polls := []time.Time{}
+	meta := newFakeClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/object-storages/os1" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		polls = append(polls, time.Now())
+		status := "PROVISIONING"
+		if len(polls) == 3 {
+			status = "READY"
+		}
+		writeJson(w, http.StatusOK, `{"data":[`+strings.Replace(testObjectStorageJson("os1"), `"status":"READY"`, `"status":"`+status+`"`, 1)+`],"_links":{"self":"/"}}`)
+	}))
+	meta.pollInterval = 50 * time.Millisecond
+
+	if err := waitForObjectStorageReady(context.Background(), meta.client, "os1", meta.pollInterval, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(polls) != 3 {
+		t.Fatalf("expected 3 polls, got %d", len(polls))
+	}
+	for i := 1; i < len(polls); i++ {
+		if gap := polls[i].Sub(polls[i-1]); gap < meta.pollInterval {
+			t.Fatalf("expected the polls to be at least %s apart, got %s", meta.pollInterval, gap)
+		}
+	}
+}
+
+func TestWaitTimeoutPrefersResourceTimeouts(t *testing.T) {
+	meta := &providerMeta{pollTimeout: 5 * time.Minute}
+
+	for _, c := range []struct {
+		config   map[string]interface{}
+		expected time.Duration
+	}{
+		{map[string]interface{}{}, 5 * time.Minute},
+		{map[string]interface{}{"timeouts": []interface{}{map[string]interface{}{"create": "2h"}}}, 2 * time.Hour},
+	} {
+		resource := resourceObjectStorage()
+		timeouts := &schema.ResourceTimeout{}
+		if err := timeouts.ConfigDecode(resource, terraform.NewResourceConfigRaw(c.config)); err != nil {
+			t.Fatal(err)
+		}
+		// the timeouts as the SDK decodes them from the configuration
+		resource.Timeouts = timeouts
+		d := resource.Data(&terraform.InstanceState{ID: "os1"})
+
+		if timeout := waitTimeout(d, meta, schema.TimeoutCreate, defaultPollTimeout); timeout != c.expected {
+			t.Errorf("expected the timeout %s for %v, got %s", c.expected, c.config, timeout)
+		}
+	}
+}
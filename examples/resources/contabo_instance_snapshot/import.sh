@@ -0,0 +1,2 @@
+# Snapshots can be imported by the id of their instance and their own id
+terraform import contabo_instance_snapshot.backup 12345/snap1628603855
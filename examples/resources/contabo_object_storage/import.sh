@@ -0,0 +1,5 @@
+# Object Storages can be imported by their id
+terraform import contabo_object_storage.eu 2f3a7b8c-1d4e-4f5a-9b6c-7d8e9f0a1b2c
+
+# or by their region, as long as there is only one Object Storage in the region
+terraform import contabo_object_storage.eu EU
@@ -0,0 +1,8 @@
+# Private Networks can be imported by their id
+terraform import contabo_private_network.databasePrivateNetwork 12345
+
+# several at once by a comma separated list of ids
+terraform import contabo_private_network.databasePrivateNetwork 12345,12346
+
+# or all Private Networks of the account at once
+terraform import contabo_private_network.databasePrivateNetwork all
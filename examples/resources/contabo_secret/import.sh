@@ -0,0 +1,5 @@
+# Secrets can be imported by their id
+terraform import contabo_secret.rootPassword 12345
+
+# or by their name, as long as the name is unique
+terraform import contabo_secret.rootPassword my_secret